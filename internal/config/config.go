@@ -4,6 +4,10 @@ import (
 	"fmt"
 	"net"
 	"os"
+	"path"
+	"regexp"
+	"slices"
+	"sort"
 	"strings"
 	"time"
 
@@ -12,18 +16,113 @@ import (
 
 const (
 	AllScrape              = "all"
+	ASScrape               = "asns"
+	CountryScrape          = "countries"
+	CustomScrape           = "custom"
+	FlowDeviceScrape       = "flowdevices"
 	HostScrape             = "hosts"
 	InterfaceScrape        = "interfaces"
 	L7Protocols            = "l7protocols"
+	MACScrape              = "macs"
+	NetworkScrape          = "networks"
+	OSScrape               = "operatingsystems"
+	PoolScrape             = "pools"
+	SNMPScrape             = "snmp"
+	SystemScrape           = "system"
 	DefaultMetricServePort = 3001
+	GaugeMetricType        = "gauge"
+	CounterMetricType      = "counter"
+	// FullHostLabelMode labels host metrics with all of the host's identifying labels, while MinimalHostLabelMode only
+	// uses the labels that are stable for the lifetime of a host and moves the rest onto ntopng_host_info
+	FullHostLabelMode    = "full"
+	MinimalHostLabelMode = "minimal"
+	// DefaultMetricNamespace is the prefix of every metric name unless another namespace is configured
+	DefaultMetricNamespace = "ntopng"
+	// DropRelabelAction, KeepRelabelAction, RenameRelabelAction and ReplaceRelabelAction are the available relabel
+	// rule actions
+	DropRelabelAction    = "drop"
+	KeepRelabelAction    = "keep"
+	RenameRelabelAction  = "rename"
+	ReplaceRelabelAction = "replace"
+	// IfIDTemplate is replaced with the ifid of each monitored interface in custom endpoint paths and query parameters
+	IfIDTemplate = "{ifid}"
+	// DefaultReverseDNSLookupsPerSecond is the default rate limit of reverse DNS lookups
+	DefaultReverseDNSLookupsPerSecond = 10
+	// LocalSubnetsFilterRule and UnmatchedIncludeFilterRule are the rule names reported for hosts that are dropped by
+	// localSubnetsOnly or that did not match any of the configured include rules
+	LocalSubnetsFilterRule     = "localSubnetsOnly"
+	UnmatchedIncludeFilterRule = "unmatchedInclude"
+	// OtherHostIP is the ip label given to the aggregate of all hosts that fall outside of the hostLimit
+	OtherHostIP = "other"
 )
 
 var (
+	macPrefixRegex         = regexp.MustCompile(`^[0-9a-fA-F]{2}([:.-]?[0-9a-fA-F]{2})*$`)
+	metricNameRegex        = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*$`)
 	AvailableScrapeTargets = map[string]bool{
-		AllScrape:       true,
-		HostScrape:      true,
-		InterfaceScrape: true,
-		L7Protocols:     true}
+		AllScrape:        true,
+		ASScrape:         true,
+		CountryScrape:    true,
+		CustomScrape:     true,
+		FlowDeviceScrape: true,
+		HostScrape:       true,
+		InterfaceScrape:  true,
+		L7Protocols:      true,
+		MACScrape:        true,
+		NetworkScrape:    true,
+		OSScrape:         true,
+		PoolScrape:       true,
+		SNMPScrape:       true,
+		SystemScrape:     true}
+	// OptInScrapeTargets are left out of "all" and are only scraped when they are listed explicitly, because they only
+	// return data on some ntopng setups (nProbe flow collection, ntopng Pro) and would otherwise cost an API call that
+	// fails on every scrape
+	OptInScrapeTargets = map[string]bool{FlowDeviceScrape: true, SNMPScrape: true}
+	// DNSMetricFamilies, ScoreMetricFamilies, etc. are the host metric families that are disabled by each of the
+	// exclude*Metrics options, they are named without the namespace just like the families in metric.disabled
+	DNSMetricFamilies        = []string{"host_total_dns_queries", "host_total_dns_replies", "host_dns_queries_by_type"}
+	ScoreMetricFamilies      = []string{"host_score"}
+	ThroughputMetricFamilies = []string{"host_current_throughput_bps", "host_current_throughput_pps"}
+	TCPMetricFamilies        = []string{"host_tcp_packet_stats"}
+	FlowHealthMetricFamilies = []string{"host_misbehaving_flows", "host_unreachable_flows"}
+	TimestampMetricFamilies  = []string{"host_duration_seconds", "host_first_seen_timestamp_seconds",
+		"host_ntopng_last_seen_timestamp_seconds"}
+	FlagMetricFamilies           = []string{"host_blacklisted", "host_is_local"}
+	OSMetricFamilies             = []string{"host_os_info"}
+	AvailableHostLimitSortFields = map[string]bool{
+		"bytes":        true,
+		"bytes.sent":   true,
+		"bytes.rcvd":   true,
+		"packets":      true,
+		"packets.sent": true,
+		"packets.rcvd": true,
+		"active_flows": true,
+		"total_flows":  true,
+		"total_alerts": true}
+	// HostLabelNames are all of the labels that identify a host on its metrics, in any host label mode
+	HostLabelNames = []string{"ip", "ifname", "mac", "name", "vlan", "owner", "device_type", "site", "pool"}
+	// HostMetricFamilies are the built-in host metric families, named without the namespace
+	HostMetricFamilies = []string{"host_active_client_flows", "host_active_server_flows", "host_blacklisted",
+		"host_bytes_rcvd", "host_bytes_sent", "host_current_throughput_bps", "host_current_throughput_pps",
+		"host_dns_queries_by_type", "host_duration_seconds", "host_filtered_total", "host_first_seen_timestamp_seconds",
+		"host_info", "host_is_local", "host_last_seen_timestamp_seconds", "host_misbehaving_flows",
+		"host_ntopng_last_seen_timestamp_seconds", "host_num_alerts", "host_os_info", "host_packets_rcvd",
+		"host_packets_sent", "host_score", "host_tcp_packet_stats", "host_total_alerts", "host_total_client_flows", "host_total_dns_queries",
+		"host_total_dns_replies", "host_total_server_flows", "host_unreachable_flows"}
+	// ReservedLabelNames are all of the labels that the built-in metric families use, in any configuration
+	ReservedLabelNames = []string{"as_name", "as_org", "asn", "cidr", "city", "country", "device_ip", "device_name",
+		"device_type", "direction", "edition", "exporter_ip", "hostname", "ifid", "ifname", "ip", "mac", "manufacturer",
+		"metric", "name", "network", "network_id", "os", "owner", "platform", "pool", "pool_id", "port_alias",
+		"port_index", "port_name", "probe_ifindex", "probe_ip", "probe_uuid", "record_type", "revision", "role", "rule",
+		"script", "site", "state", "status", "subnet", "table", "type", "vendor", "version", "vlan"}
+	// InventoryLabelNames are the labels that carry data from the inventory, which are only used when an inventory is
+	// configured
+	InventoryLabelNames = []string{"device_type", "hostname", "owner", "site"}
+	// BuiltInMetricPrefixes are the subsystems of the built-in metric families, along with the names of the families
+	// that don't have one
+	BuiltInMetricPrefixes = []string{"as", "build_info", "clickhouse", "counter_resets_total", "country", "exporter",
+		"flowdevice", "host", "interface", "license", "mac", "network", "os", "periodic_script", "pool", "redis",
+		"resident_memory_bytes", "rollup_asn", "rollup_country", "snmp", "subnet", "system", "uptime_seconds", "vlan"}
 )
 
 type ntopng struct {
@@ -42,9 +141,189 @@ type host struct {
 }
 
 type metric struct {
-	LocalSubnetsOnly  []string
-	ExcludeDNSMetrics bool
-	Serve             metricServe
+	LocalSubnetsOnly         []string
+	ExcludeDNSMetrics        bool
+	ExcludeScoreMetrics      bool
+	ExcludeThroughputMetrics bool
+	ExcludeTCPMetrics        bool
+	ExcludeFlowHealthMetrics bool
+	ExcludeTimestampMetrics  bool
+	ExcludeFlagMetrics       bool
+	ExcludeOSMetrics         bool
+	Disabled                 []string
+	Namespace                string
+	ConstLabels              map[string]string
+	Relabel                  []relabelRule
+	HostFilters              hostFilters
+	HostLimit                hostLimit
+	HostRetention            int
+	MonotonicCounters        monotonicCounters
+	SampleTimestamps         bool
+	HostInfoMetric           bool
+	HostLabelMode            string
+	HostPoolLabel            bool
+	CustomHostFields         []customHostField
+	CustomEndpoints          []customEndpoint
+	Rollups                  rollups
+	Serve                    metricServe
+}
+
+// customHostField maps a field that ntopng returns for each host onto a metric, so that fields the exporter doesn't know
+// about can be exported without code changes
+type customHostField struct {
+	// Field is the top level ntopng host field to request, e.g. score.as_client or ndpi
+	Field string
+	// Path optionally selects a value within Field, segments are separated by dots and * matches every key
+	Path string
+	// Name of the metric, it is exported as ntopng_host_<name>
+	Name string
+	Type string
+	Help string
+	// KeyLabels name the labels that the keys matched by each * in Path are exported as
+	KeyLabels []string
+}
+
+// IsMetricDisabled returns true if the metric family, named without the namespace, matches one of the disabled patterns
+func (m *metric) IsMetricDisabled(family string) bool {
+	for _, pattern := range m.Disabled {
+		if matched, _ := path.Match(pattern, family); matched {
+			return true
+		}
+	}
+	return false
+}
+
+// AnyMetricEnabled returns true if at least one of the metric families hasn't been disabled
+func (m *metric) AnyMetricEnabled(families []string) bool {
+	for _, family := range families {
+		if !m.IsMetricDisabled(family) {
+			return true
+		}
+	}
+	return false
+}
+
+// MinimalHostLabels returns true if host metrics should only be labelled by ip, ifname and vlan
+func (m *metric) MinimalHostLabels() bool {
+	return m.HostLabelMode == MinimalHostLabelMode
+}
+
+func (f *customHostField) IsCounter() bool {
+	return f.Type == CounterMetricType
+}
+
+// relabelRule is applied to each metric family whose name matches Metrics, in the order that the rules are defined
+type relabelRule struct {
+	// Action is one of drop, keep, rename or replace
+	Action string
+	// Metrics is a regular expression that has to match the whole metric name, including the namespace
+	Metrics string
+	// Label is the label to rename or to replace the value of
+	Label string
+	// TargetLabel is the new name of Label for the rename action
+	TargetLabel string
+	// Regex has to match the whole value of Label for the replace action to change it to Replacement, which can refer
+	// to the capture groups of Regex as $1, $2, etc.
+	Regex       string
+	Replacement string
+}
+
+// customEndpoint is an ntopng REST endpoint, that the exporter doesn't otherwise know about, to extract metrics from
+type customEndpoint struct {
+	// Name of the endpoint, metrics are exported as ntopng_<name>_<metric name>
+	Name string
+	// Path of the endpoint below /lua/rest/v2/get, e.g. /alert/type/counters.lua
+	Path string
+	// Query parameters to send, any {ifid} within the path or a value causes the endpoint to be requested once for
+	// each monitored interface with an ifname label added to its metrics
+	Query   map[string]string
+	Metrics []customEndpointMetric
+}
+
+type customEndpointMetric struct {
+	Name string
+	Type string
+	Help string
+	// Path selects the items within the rsp of the endpoint to create a metric for, * matches every key or index
+	Path string
+	// Value is the path to the metric value within each item, by default the item itself is used
+	Value string
+	// KeyLabels name the labels that the keys matched by each * in Path are exported as, keys given an empty name aren't
+	// exported (e.g. the indexes of array items)
+	KeyLabels []string
+	// Labels maps label names to paths within each item
+	Labels map[string]string
+}
+
+// PerInterface returns true if the endpoint must be requested for each monitored interface
+func (e *customEndpoint) PerInterface() bool {
+	if strings.Contains(e.Path, IfIDTemplate) {
+		return true
+	}
+	for _, value := range e.Query {
+		if strings.Contains(value, IfIDTemplate) {
+			return true
+		}
+	}
+	return false
+}
+
+func (m *customEndpointMetric) IsCounter() bool {
+	return m.Type == CounterMetricType
+}
+
+// LabelNames returns the names of the labels of the metric, the named key labels first followed by the sorted names of
+// Labels
+func (m *customEndpointMetric) LabelNames() []string {
+	var keyLabels, labels []string
+	for _, label := range m.KeyLabels {
+		if label != "" {
+			keyLabels = append(keyLabels, label)
+		}
+	}
+	for label := range m.Labels {
+		labels = append(labels, label)
+	}
+	sort.Strings(labels)
+	return append(keyLabels, labels...)
+}
+
+type rollups struct {
+	Subnets []namedSubnet
+	VLANs   bool
+}
+
+type namedSubnet struct {
+	Name string
+	CIDR string
+}
+
+type hostLimit struct {
+	MaxHosts int
+	SortBy   string
+}
+
+// monotonicCounters makes the exporter keep its own totals of every counter so that they keep increasing when ntopng
+// restarts or resets its values
+type monotonicCounters struct {
+	Enabled bool
+	// Expiry is how long the totals of a series that is no longer exported are kept
+	Expiry string
+}
+
+type hostFilters struct {
+	Include []hostFilterRule
+	Exclude []hostFilterRule
+}
+
+type hostFilterRule struct {
+	Name        string
+	Subnets     []string
+	MACPrefixes []string
+	NameRegex   string
+	VLANs       []int
+	Interfaces  []string
+	MinBytes    float64
 }
 
 type metricServe struct {
@@ -52,10 +331,39 @@ type metricServe struct {
 	Port int
 }
 
+type enrichment struct {
+	Inventory   inventory
+	OUIDatabase string
+	GeoIP       geoIP
+	ReverseDNS  reverseDNS
+}
+
+type reverseDNS struct {
+	Subnets          []string
+	Server           string
+	CacheTTL         string
+	NegativeCacheTTL string
+	Timeout          string
+	LookupsPerSecond int
+}
+
+type geoIP struct {
+	CountryDatabase string
+	CityDatabase    string
+	ASNDatabase     string
+	InfoLabels      bool
+}
+
+type inventory struct {
+	Path           string
+	OverrideLabels bool
+}
+
 type Config struct {
-	Ntopng ntopng
-	Host   host
-	Metric metric
+	Ntopng     ntopng
+	Host       host
+	Metric     metric
+	Enrichment enrichment
 }
 
 func ParseConfig() (Config, error) {
@@ -74,6 +382,30 @@ func ParseConfig() (Config, error) {
 
 	// Set default values
 	viper.SetDefault("metric.excludeDNSMetrics", false)
+	viper.SetDefault("metric.excludeScoreMetrics", false)
+	viper.SetDefault("metric.excludeThroughputMetrics", false)
+	viper.SetDefault("metric.excludeTCPMetrics", false)
+	viper.SetDefault("metric.excludeFlowHealthMetrics", false)
+	viper.SetDefault("metric.excludeTimestampMetrics", false)
+	viper.SetDefault("metric.excludeFlagMetrics", false)
+	viper.SetDefault("metric.excludeOSMetrics", false)
+	viper.SetDefault("metric.hostLimit.maxHosts", 0)
+	viper.SetDefault("metric.hostLimit.sortBy", "bytes")
+	viper.SetDefault("metric.hostRetention", 0)
+	viper.SetDefault("metric.monotonicCounters.enabled", false)
+	viper.SetDefault("metric.monotonicCounters.expiry", "1h")
+	viper.SetDefault("metric.sampleTimestamps", false)
+	viper.SetDefault("metric.rollups.vlans", false)
+	viper.SetDefault("metric.namespace", DefaultMetricNamespace)
+	viper.SetDefault("metric.hostInfoMetric", false)
+	viper.SetDefault("metric.hostLabelMode", FullHostLabelMode)
+	viper.SetDefault("metric.hostPoolLabel", false)
+	viper.SetDefault("enrichment.inventory.overrideLabels", false)
+	viper.SetDefault("enrichment.geoip.infoLabels", false)
+	viper.SetDefault("enrichment.reverseDNS.cacheTTL", "1h")
+	viper.SetDefault("enrichment.reverseDNS.negativeCacheTTL", "5m")
+	viper.SetDefault("enrichment.reverseDNS.timeout", "2s")
+	viper.SetDefault("enrichment.reverseDNS.lookupsPerSecond", DefaultReverseDNSLookupsPerSecond)
 	viper.SetDefault("ntopng.scrapeInterval", "1m")
 	viper.SetDefault("ntopng.metric.serve.ip", "0.0.0.0")
 	viper.SetDefault("ntopng.metric.serve.port", DefaultMetricServePort)
@@ -122,6 +454,97 @@ func (c *Config) validate() error {
 			}
 		}
 	}
+	if err := c.Metric.HostFilters.validate(); err != nil {
+		return err
+	}
+	for _, exclude := range []struct {
+		excluded bool
+		families []string
+	}{
+		{c.Metric.ExcludeDNSMetrics, DNSMetricFamilies},
+		{c.Metric.ExcludeScoreMetrics, ScoreMetricFamilies},
+		{c.Metric.ExcludeThroughputMetrics, ThroughputMetricFamilies},
+		{c.Metric.ExcludeTCPMetrics, TCPMetricFamilies},
+		{c.Metric.ExcludeFlowHealthMetrics, FlowHealthMetricFamilies},
+		{c.Metric.ExcludeTimestampMetrics, TimestampMetricFamilies},
+		{c.Metric.ExcludeFlagMetrics, FlagMetricFamilies},
+		{c.Metric.ExcludeOSMetrics, OSMetricFamilies},
+	} {
+		if exclude.excluded {
+			c.Metric.Disabled = append(c.Metric.Disabled, exclude.families...)
+		}
+	}
+	for _, family := range c.Metric.Disabled {
+		if _, err := path.Match(family, ""); family == "" || err != nil {
+			return fmt.Errorf("disabled metric family '%s' is not a valid pattern", family)
+		}
+	}
+	if c.Metric.Namespace != "" && !metricNameRegex.MatchString(c.Metric.Namespace) {
+		return fmt.Errorf("metric namespace '%s' must be made of letters, numbers and underscores", c.Metric.Namespace)
+	}
+	constLabelNames := make([]string, 0, len(c.Metric.ConstLabels))
+	for label := range c.Metric.ConstLabels {
+		constLabelNames = append(constLabelNames, label)
+	}
+	sort.Strings(constLabelNames)
+	if err := validateLabelNames(constLabelNames); err != nil {
+		return fmt.Errorf("metric constLabels %v", err)
+	}
+	if err := validateRelabelRules(c.Metric.Relabel); err != nil {
+		return err
+	}
+	if err := validateCustomHostFields(c.Metric.CustomHostFields); err != nil {
+		return err
+	}
+	if err := validateCustomEndpoints(c.Metric.CustomEndpoints); err != nil {
+		return err
+	}
+	if err := c.Metric.validateLabelCollisions(constLabelNames, c.reservedLabelNames()); err != nil {
+		return err
+	}
+	c.Metric.HostLabelMode = strings.ToLower(c.Metric.HostLabelMode)
+	if c.Metric.HostLabelMode != FullHostLabelMode && c.Metric.HostLabelMode != MinimalHostLabelMode {
+		return fmt.Errorf("metric hostLabelMode must be either full or minimal")
+	}
+	// Without the info metric there would be no way to find out the name or MAC of a host in minimal mode
+	if c.Metric.HostLabelMode == MinimalHostLabelMode {
+		c.Metric.HostInfoMetric = true
+	}
+	// The vendor from the OUI database and the GeoIP info labels are only exported on the info metric
+	if c.Enrichment.OUIDatabase != "" || c.Enrichment.GeoIP.InfoLabels {
+		c.Metric.HostInfoMetric = true
+	}
+	if c.Metric.HostLimit.MaxHosts < 0 {
+		return fmt.Errorf("hostLimit maxHosts cannot be negative")
+	}
+	if c.Metric.HostRetention < 0 {
+		return fmt.Errorf("hostRetention cannot be negative")
+	}
+	counterExpiry, err := time.ParseDuration(c.Metric.MonotonicCounters.Expiry)
+	if err != nil {
+		return fmt.Errorf("was not able to parse configured monotonicCounters expiry: %s - %v",
+			c.Metric.MonotonicCounters.Expiry, err)
+	}
+	if counterExpiry <= 0 {
+		return fmt.Errorf("monotonicCounters expiry must be greater than zero")
+	}
+	if !AvailableHostLimitSortFields[c.Metric.HostLimit.SortBy] {
+		return fmt.Errorf("'%s' is not an available hostLimit sortBy field: %v",
+			c.Metric.HostLimit.SortBy, AvailableHostLimitSortFields)
+	}
+	subnetNames := make(map[string]bool)
+	for _, subnet := range c.Metric.Rollups.Subnets {
+		if subnet.Name == "" {
+			return fmt.Errorf("every rollup subnet must have a name")
+		}
+		if subnetNames[subnet.Name] {
+			return fmt.Errorf("rollup subnet name '%s' is used more than once", subnet.Name)
+		}
+		subnetNames[subnet.Name] = true
+		if _, _, err := net.ParseCIDR(subnet.CIDR); err != nil {
+			return fmt.Errorf("rollup subnet '%s' does not have a valid CIDR: '%s' - %v", subnet.Name, subnet.CIDR, err)
+		}
+	}
 	if _, err := time.ParseDuration(c.Ntopng.ScrapeInterval); err != nil {
 		return fmt.Errorf("was not able to parse configured duration: %s - %v", c.Ntopng.ScrapeInterval, err)
 	}
@@ -140,6 +563,28 @@ func (c *Config) validate() error {
 			return fmt.Errorf("it looks like address isn't present on the host to bind to: %s", c.Metric.Serve.IP)
 		}
 	}
+	if c.Enrichment.Inventory.Path != "" {
+		if _, err := os.Stat(c.Enrichment.Inventory.Path); err != nil {
+			return fmt.Errorf("was not able to find inventory file: %v", err)
+		}
+	}
+	if c.Enrichment.OUIDatabase != "" {
+		if _, err := os.Stat(c.Enrichment.OUIDatabase); err != nil {
+			return fmt.Errorf("was not able to find OUI database: %v", err)
+		}
+	}
+	for _, database := range []string{c.Enrichment.GeoIP.CountryDatabase, c.Enrichment.GeoIP.CityDatabase,
+		c.Enrichment.GeoIP.ASNDatabase} {
+		if database == "" {
+			continue
+		}
+		if _, err := os.Stat(database); err != nil {
+			return fmt.Errorf("was not able to find GeoIP database: %v", err)
+		}
+	}
+	if err := c.Enrichment.ReverseDNS.validate(); err != nil {
+		return err
+	}
 	if len(c.Ntopng.ScrapeTargets) < 1 {
 		return fmt.Errorf("you must specify at least one scrape target in the config")
 	}
@@ -152,8 +597,329 @@ func (c *Config) validate() error {
 	return nil
 }
 
+func validateRelabelRules(rules []relabelRule) error {
+	for i := range rules {
+		rule := &rules[i]
+		if rule.Metrics == "" {
+			rule.Metrics = ".*"
+		}
+		if _, err := regexp.Compile(rule.Metrics); err != nil {
+			return fmt.Errorf("relabel rule %d metrics regex '%s' does not compile: %v", i+1, rule.Metrics, err)
+		}
+		switch rule.Action {
+		case DropRelabelAction, KeepRelabelAction:
+		case RenameRelabelAction:
+			if err := validateLabelNames([]string{rule.Label, rule.TargetLabel}); err != nil {
+				return fmt.Errorf("relabel rule %d must have a different label and targetLabel: %v", i+1, err)
+			}
+		case ReplaceRelabelAction:
+			if err := validateLabelNames([]string{rule.Label}); err != nil {
+				return fmt.Errorf("relabel rule %d must have a label to replace: %v", i+1, err)
+			}
+			if rule.Regex == "" {
+				rule.Regex = "(.*)"
+			}
+			if _, err := regexp.Compile(rule.Regex); err != nil {
+				return fmt.Errorf("relabel rule %d regex '%s' does not compile: %v", i+1, rule.Regex, err)
+			}
+		default:
+			return fmt.Errorf("relabel rule %d action must be either drop, keep, rename or replace", i+1)
+		}
+	}
+	return nil
+}
+
+// reservedLabelNames returns the labels that the built-in metric families use with this config. The inventory labels
+// and the pool label are only reserved when they are exported, so that e.g. site can be a constant label otherwise.
+func (c *Config) reservedLabelNames() []string {
+	inventoryLabels := c.Enrichment.Inventory.Enabled() || c.Enrichment.Inventory.OverrideLabels
+	poolLabel := c.Metric.HostPoolLabel || c.IsScrapeTargetEnabled(PoolScrape)
+	return slices.DeleteFunc(slices.Clone(ReservedLabelNames), func(label string) bool {
+		if slices.Contains(InventoryLabelNames, label) {
+			return !inventoryLabels
+		}
+		return label == "pool" && !poolLabel
+	})
+}
+
+// validateLabelCollisions makes sure that constant labels and the target labels of rename rules don't collide with the
+// labels of any metric family, as the exporter wouldn't be able to create the family otherwise. Labels are checked
+// against every family rather than only those that a rule matches, so that new families can't break existing configs.
+func (m *metric) validateLabelCollisions(constLabelNames []string, reservedLabelNames []string) error {
+	usedLabels := make(map[string]string)
+	for _, field := range m.CustomHostFields {
+		for _, label := range field.KeyLabels {
+			usedLabels[label] = fmt.Sprintf("custom host field '%s'", field.Name)
+		}
+	}
+	for _, endpoint := range m.CustomEndpoints {
+		for _, endpointMetric := range endpoint.Metrics {
+			for _, label := range endpointMetric.LabelNames() {
+				usedLabels[label] = fmt.Sprintf("custom endpoint '%s' metric '%s'", endpoint.Name, endpointMetric.Name)
+			}
+		}
+	}
+	for _, label := range reservedLabelNames {
+		usedLabels[label] = "built-in metrics"
+	}
+	for _, label := range constLabelNames {
+		if usedBy, ok := usedLabels[label]; ok {
+			return fmt.Errorf("metric constLabels label '%s' is already used by %s", label, usedBy)
+		}
+		usedLabels[label] = "metric constLabels"
+	}
+	for i, rule := range m.Relabel {
+		if rule.Action != RenameRelabelAction {
+			continue
+		}
+		if usedBy, ok := usedLabels[rule.TargetLabel]; ok {
+			return fmt.Errorf("relabel rule %d targetLabel '%s' is already used by %s", i+1, rule.TargetLabel, usedBy)
+		}
+		usedLabels[rule.TargetLabel] = fmt.Sprintf("relabel rule %d", i+1)
+	}
+	return nil
+}
+
+func validateCustomHostFields(fields []customHostField) error {
+	names := make(map[string]bool)
+	for i := range fields {
+		field := &fields[i]
+		if field.Field == "" {
+			return fmt.Errorf("every custom host field must have a field")
+		}
+		if !metricNameRegex.MatchString(field.Name) {
+			return fmt.Errorf("custom host field '%s' must have a name made of letters, numbers and underscores",
+				field.Field)
+		}
+		if names[field.Name] {
+			return fmt.Errorf("custom host field name '%s' is used more than once", field.Name)
+		}
+		names[field.Name] = true
+		if slices.Contains(HostMetricFamilies, "host_"+field.Name) {
+			return fmt.Errorf("custom host field name '%s' is already used by a built-in host metric", field.Name)
+		}
+		if err := validateMetricType(&field.Type); err != nil {
+			return fmt.Errorf("custom host field '%s' %v", field.Name, err)
+		}
+		if field.Help == "" {
+			field.Help = fmt.Sprintf("value of the ntopng host field %s", field.Field)
+			if field.Path != "" {
+				field.Help = fmt.Sprintf("%s at path %s", field.Help, field.Path)
+			}
+		}
+		if countPathWildcards(field.Path) != len(field.KeyLabels) {
+			return fmt.Errorf("custom host field '%s' must have one key label for each * in its path", field.Name)
+		}
+		if err := validateLabelNames(field.KeyLabels); err != nil {
+			return fmt.Errorf("custom host field '%s' %v", field.Name, err)
+		}
+		for _, label := range field.KeyLabels {
+			if slices.Contains(HostLabelNames, label) {
+				return fmt.Errorf("custom host field '%s' key label '%s' is already used by the host labels",
+					field.Name, label)
+			}
+		}
+	}
+	return nil
+}
+
+func validateCustomEndpoints(endpoints []customEndpoint) error {
+	names := make(map[string]bool)
+	for i := range endpoints {
+		endpoint := &endpoints[i]
+		if !metricNameRegex.MatchString(endpoint.Name) {
+			return fmt.Errorf("every custom endpoint must have a name made of letters, numbers and underscores")
+		}
+		if names[endpoint.Name] {
+			return fmt.Errorf("custom endpoint name '%s' is used more than once", endpoint.Name)
+		}
+		names[endpoint.Name] = true
+		if prefix := builtInMetricPrefix(endpoint.Name); prefix != "" {
+			return fmt.Errorf("custom endpoint name '%s' would collide with the built-in %s metrics", endpoint.Name,
+				prefix)
+		}
+		if !strings.HasPrefix(endpoint.Path, "/") {
+			return fmt.Errorf("custom endpoint '%s' must have a path starting with /", endpoint.Name)
+		}
+		if len(endpoint.Metrics) < 1 {
+			return fmt.Errorf("custom endpoint '%s' must define at least one metric", endpoint.Name)
+		}
+		metricNames := make(map[string]bool)
+		for j := range endpoint.Metrics {
+			metric := &endpoint.Metrics[j]
+			if !metricNameRegex.MatchString(metric.Name) {
+				return fmt.Errorf("custom endpoint '%s' has a metric without a name made of letters, numbers and "+
+					"underscores", endpoint.Name)
+			}
+			if metricNames[metric.Name] {
+				return fmt.Errorf("custom endpoint '%s' metric name '%s' is used more than once", endpoint.Name,
+					metric.Name)
+			}
+			metricNames[metric.Name] = true
+			if err := validateMetricType(&metric.Type); err != nil {
+				return fmt.Errorf("custom endpoint '%s' metric '%s' %v", endpoint.Name, metric.Name, err)
+			}
+			if metric.Help == "" {
+				metric.Help = fmt.Sprintf("value at path %s of the ntopng endpoint %s", metric.Path, endpoint.Path)
+			}
+			if countPathWildcards(metric.Path) != len(metric.KeyLabels) {
+				return fmt.Errorf("custom endpoint '%s' metric '%s' must have one key label for each * in its path",
+					endpoint.Name, metric.Name)
+			}
+			if countPathWildcards(metric.Value) > 0 {
+				return fmt.Errorf("custom endpoint '%s' metric '%s' value cannot contain a *", endpoint.Name,
+					metric.Name)
+			}
+			for label, path := range metric.Labels {
+				if countPathWildcards(path) > 0 {
+					return fmt.Errorf("custom endpoint '%s' metric '%s' label '%s' cannot contain a *", endpoint.Name,
+						metric.Name, label)
+				}
+			}
+			labelNames := metric.LabelNames()
+			if endpoint.PerInterface() {
+				labelNames = append(labelNames, "ifname")
+			}
+			if err := validateLabelNames(labelNames); err != nil {
+				return fmt.Errorf("custom endpoint '%s' metric '%s' %v", endpoint.Name, metric.Name, err)
+			}
+		}
+	}
+	return nil
+}
+
+// validateMetricType checks that the metric type is either gauge or counter, defaulting it to gauge if it isn't set
+func validateMetricType(metricType *string) error {
+	if *metricType == "" {
+		*metricType = GaugeMetricType
+	}
+	if *metricType != GaugeMetricType && *metricType != CounterMetricType {
+		return fmt.Errorf("type must be either gauge or counter")
+	}
+	return nil
+}
+
+func validateLabelNames(labels []string) error {
+	seen := make(map[string]bool)
+	for _, label := range labels {
+		if !metricNameRegex.MatchString(label) {
+			return fmt.Errorf("label '%s' must be made of letters, numbers and underscores", label)
+		}
+		if seen[label] {
+			return fmt.Errorf("label '%s' is used more than once", label)
+		}
+		seen[label] = true
+	}
+	return nil
+}
+
+// builtInMetricPrefix returns the built-in metric prefix that metric families prefixed with name could collide with, or
+// an empty string if there isn't one. Names that a built-in prefix starts with are included, as "periodic" followed by
+// the metric name "script_runs" would otherwise collide with the periodic_script metrics.
+func builtInMetricPrefix(name string) string {
+	for _, prefix := range BuiltInMetricPrefixes {
+		if name == prefix || strings.HasPrefix(name, prefix+"_") || strings.HasPrefix(prefix, name+"_") {
+			return prefix
+		}
+	}
+	return ""
+}
+
+// countPathWildcards returns the number of * segments in a dot separated path
+func countPathWildcards(path string) int {
+	wildcards := 0
+	for _, segment := range strings.Split(path, ".") {
+		if segment == "*" {
+			wildcards++
+		}
+	}
+	return wildcards
+}
+
+func (hf *hostFilters) validate() error {
+	names := make(map[string]bool)
+	for _, rules := range [][]hostFilterRule{hf.Include, hf.Exclude} {
+		for _, rule := range rules {
+			if rule.Name == "" {
+				return fmt.Errorf("every host filter rule must have a name")
+			}
+			if rule.Name == LocalSubnetsFilterRule || rule.Name == UnmatchedIncludeFilterRule {
+				return fmt.Errorf("host filter rule name '%s' is reserved", rule.Name)
+			}
+			if names[rule.Name] {
+				return fmt.Errorf("host filter rule name '%s' is used more than once", rule.Name)
+			}
+			names[rule.Name] = true
+			if err := rule.validate(); err != nil {
+				return fmt.Errorf("host filter rule '%s' is not valid: %v", rule.Name, err)
+			}
+		}
+	}
+	return nil
+}
+
+func (r *hostFilterRule) validate() error {
+	if len(r.Subnets) < 1 && len(r.MACPrefixes) < 1 && r.NameRegex == "" && len(r.VLANs) < 1 &&
+		len(r.Interfaces) < 1 && r.MinBytes <= 0 {
+		return fmt.Errorf("rule must define at least one match criteria")
+	}
+	for _, subnet := range r.Subnets {
+		if _, _, err := net.ParseCIDR(subnet); err != nil {
+			return fmt.Errorf("subnet specified: '%s', is not a valid subnet: %v", subnet, err)
+		}
+	}
+	for _, prefix := range r.MACPrefixes {
+		if !macPrefixRegex.MatchString(prefix) {
+			return fmt.Errorf("MAC prefix specified: '%s', is not a valid MAC prefix", prefix)
+		}
+	}
+	if r.NameRegex != "" {
+		if _, err := regexp.Compile(r.NameRegex); err != nil {
+			return fmt.Errorf("name regex specified: '%s', does not compile: %v", r.NameRegex, err)
+		}
+	}
+	if r.MinBytes < 0 {
+		return fmt.Errorf("minBytes cannot be negative")
+	}
+	return nil
+}
+
+func (r *reverseDNS) validate() error {
+	for _, subnet := range r.Subnets {
+		if _, _, err := net.ParseCIDR(subnet); err != nil {
+			return fmt.Errorf("reverse DNS subnet specified: '%s', is not a valid subnet: %v", subnet, err)
+		}
+	}
+	if r.Server != "" {
+		if _, _, err := net.SplitHostPort(r.Server); err != nil {
+			return fmt.Errorf("reverse DNS server must be in the form of host:port: %v", err)
+		}
+	}
+	for _, duration := range []string{r.CacheTTL, r.NegativeCacheTTL, r.Timeout} {
+		if _, err := time.ParseDuration(duration); err != nil {
+			return fmt.Errorf("was not able to parse configured reverse DNS duration: %s - %v", duration, err)
+		}
+	}
+	if r.LookupsPerSecond < 1 {
+		return fmt.Errorf("reverse DNS lookupsPerSecond must be at least 1")
+	}
+	return nil
+}
+
+// IsScrapeTargetEnabled returns true if the target was configured explicitly or if all targets are to be scraped and
+// the target isn't an opt-in target
+func (c *Config) IsScrapeTargetEnabled(target string) bool {
+	for _, configuredTarget := range c.Ntopng.ScrapeTargets {
+		if configuredTarget == target || (configuredTarget == AllScrape && !OptInScrapeTargets[target]) {
+			return true
+		}
+	}
+	return false
+}
+
 func (c Config) String() string {
-	configOutput := fmt.Sprintf("ntopng:\n%s\n\nhost:\n%s\n\nmetric:\n%s", c.Ntopng, c.Host, c.Metric)
+	configOutput := fmt.Sprintf("ntopng:\n%s\n\nhost:\n%s\n\nmetric:\n%s\n\nenrichment:\n%s",
+		c.Ntopng, c.Host, c.Metric, c.Enrichment)
 	return configOutput
 }
 
@@ -167,10 +933,67 @@ func (h host) String() string {
 }
 
 func (m metric) String() string {
-	return fmt.Sprintf("\tLocal Subnets: %v\n\tExclude DNS Metrics? %t\n\tServe:\n%s",
-		m.LocalSubnetsOnly, m.ExcludeDNSMetrics, m.Serve)
+	return fmt.Sprintf("\tLocal Subnets: %v\n\tExclude DNS Metrics? %t\n\tExclude Score Metrics? %t"+
+		"\n\tExclude Throughput Metrics? %t\n\tExclude TCP Metrics? %t\n\tExclude Flow Health Metrics? %t"+
+		"\n\tExclude Timestamp Metrics? %t\n\tExclude Flag Metrics? %t\n\tExclude OS Metrics? %t"+
+		"\n\tDisabled Metrics: %v\n\tNamespace: %s"+
+		"\n\tConst Labels: %v\n\tRelabel Rules: %v\n\tHost Filters:\n%s\n\tHost Limit:\n%s\n\tHost Retention: %d"+
+		"\n\tMonotonic Counters:\n%s\n\tSample Timestamps? %t\n\tHost Info Metric? %t"+
+		"\n\tHost Label Mode: %s\n\tHost Pool Label? %t\n\tCustom Host Fields: %v\n\tCustom Endpoints: %v"+
+		"\n\tRollups:\n%s\n\tServe:\n%s",
+		m.LocalSubnetsOnly, m.ExcludeDNSMetrics, m.ExcludeScoreMetrics, m.ExcludeThroughputMetrics, m.ExcludeTCPMetrics,
+		m.ExcludeFlowHealthMetrics, m.ExcludeTimestampMetrics, m.ExcludeFlagMetrics, m.ExcludeOSMetrics, m.Disabled,
+		m.Namespace,
+		m.ConstLabels, m.Relabel, m.HostFilters, m.HostLimit, m.HostRetention, m.MonotonicCounters,
+		m.SampleTimestamps, m.HostInfoMetric, m.HostLabelMode, m.HostPoolLabel,
+		m.CustomHostFields, m.CustomEndpoints, m.Rollups, m.Serve)
+}
+
+func (r rollups) String() string {
+	return fmt.Sprintf("\t\tSubnets: %v\n\t\tVLANs? %t", r.Subnets, r.VLANs)
+}
+
+func (hl hostLimit) String() string {
+	return fmt.Sprintf("\t\tMax Hosts: %d\n\t\tSort By: %s", hl.MaxHosts, hl.SortBy)
+}
+
+func (mc monotonicCounters) String() string {
+	return fmt.Sprintf("\t\tEnabled? %t\n\t\tExpiry: %s", mc.Enabled, mc.Expiry)
+}
+
+func (hf hostFilters) String() string {
+	return fmt.Sprintf("\t\tInclude: %v\n\t\tExclude: %v", hf.Include, hf.Exclude)
 }
 
 func (ms metricServe) String() string {
 	return fmt.Sprintf("\t\tIP: %s\n\t\tPort: %d", ms.IP, ms.Port)
 }
+
+func (e enrichment) String() string {
+	return fmt.Sprintf("\tInventory:\n%s\n\tOUI Database: %s\n\tGeoIP:\n%s\n\tReverse DNS:\n%s",
+		e.Inventory, e.OUIDatabase, e.GeoIP, e.ReverseDNS)
+}
+
+func (i inventory) String() string {
+	return fmt.Sprintf("\t\tPath: %s\n\t\tOverride Labels? %t", i.Path, i.OverrideLabels)
+}
+
+// Enabled returns true if an inventory file is configured
+func (i inventory) Enabled() bool {
+	return i.Path != ""
+}
+
+func (g geoIP) String() string {
+	return fmt.Sprintf("\t\tCountry Database: %s\n\t\tCity Database: %s\n\t\tASN Database: %s\n\t\tInfo Labels? %t",
+		g.CountryDatabase, g.CityDatabase, g.ASNDatabase, g.InfoLabels)
+}
+
+// Enabled returns true if at least one GeoIP database is configured
+func (g geoIP) Enabled() bool {
+	return g.CountryDatabase != "" || g.CityDatabase != "" || g.ASNDatabase != ""
+}
+
+func (r reverseDNS) String() string {
+	return fmt.Sprintf("\t\tSubnets: %v\n\t\tServer: %s\n\t\tCache TTL: %s\n\t\tNegative Cache TTL: %s\n\t\tTimeout: %s"+
+		"\n\t\tLookups Per Second: %d", r.Subnets, r.Server, r.CacheTTL, r.NegativeCacheTTL, r.Timeout, r.LookupsPerSecond)
+}
@@ -0,0 +1,230 @@
+package config
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestValidateCustomHostFields(t *testing.T) {
+	tests := []struct {
+		name    string
+		fields  []customHostField
+		wantErr string
+	}{
+		{
+			name:   "valid field",
+			fields: []customHostField{{Field: "ndpi", Path: "*.bytes.sent", Name: "ndpi_bytes_sent", KeyLabels: []string{"protocol"}}},
+		},
+		{
+			name:    "name used twice",
+			fields:  []customHostField{{Field: "a", Name: "value"}, {Field: "b", Name: "value"}},
+			wantErr: "used more than once",
+		},
+		{
+			name:    "name of a built-in host metric",
+			fields:  []customHostField{{Field: "bytes.sent", Name: "bytes_sent"}},
+			wantErr: "already used by a built-in host metric",
+		},
+		{
+			name:    "key label that is a host label",
+			fields:  []customHostField{{Field: "ndpi", Path: "*.bytes.sent", Name: "ndpi_bytes_sent", KeyLabels: []string{"name"}}},
+			wantErr: "key label 'name' is already used by the host labels",
+		},
+		{
+			name:    "key label that is only a host label in some modes",
+			fields:  []customHostField{{Field: "ndpi", Path: "*.bytes.sent", Name: "ndpi_bytes_sent", KeyLabels: []string{"pool"}}},
+			wantErr: "key label 'pool' is already used by the host labels",
+		},
+		{
+			name:    "missing key label",
+			fields:  []customHostField{{Field: "ndpi", Path: "*.bytes.sent", Name: "ndpi_bytes_sent"}},
+			wantErr: "one key label for each *",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateCustomHostFields(tt.fields)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("validateCustomHostFields() returned error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("validateCustomHostFields() error = %v, want an error containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidateCustomEndpoints(t *testing.T) {
+	endpoint := func(name string) customEndpoint {
+		return customEndpoint{Name: name, Path: "/alert/type/counters.lua",
+			Metrics: []customEndpointMetric{{Name: "engaged", Path: "*", KeyLabels: []string{""}, Value: "count"}}}
+	}
+	tests := []struct {
+		name      string
+		endpoints []customEndpoint
+		wantErr   string
+	}{
+		{name: "valid endpoint", endpoints: []customEndpoint{endpoint("alert")}},
+		{name: "name that shares the start of a subsystem", endpoints: []customEndpoint{endpoint("hosts")}},
+		{
+			name:      "name used twice",
+			endpoints: []customEndpoint{endpoint("alert"), endpoint("alert")},
+			wantErr:   "used more than once",
+		},
+		{
+			name:      "name of a built-in subsystem",
+			endpoints: []customEndpoint{endpoint("host")},
+			wantErr:   "would collide with the built-in host metrics",
+		},
+		{
+			name:      "name within a built-in subsystem",
+			endpoints: []customEndpoint{endpoint("interface_alerts")},
+			wantErr:   "would collide with the built-in interface metrics",
+		},
+		{
+			name:      "name that a built-in subsystem starts with",
+			endpoints: []customEndpoint{endpoint("periodic")},
+			wantErr:   "would collide with the built-in periodic_script metrics",
+		},
+		{
+			name:      "name that a built-in family without a subsystem starts with",
+			endpoints: []customEndpoint{endpoint("uptime")},
+			wantErr:   "would collide with the built-in uptime_seconds metrics",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateCustomEndpoints(tt.endpoints)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("validateCustomEndpoints() returned error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("validateCustomEndpoints() error = %v, want an error containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidateLabelCollisions(t *testing.T) {
+	tests := []struct {
+		name      string
+		configure func(*Config)
+		wantErr   string
+	}{
+		{name: "no collisions", configure: func(c *Config) {
+			c.Metric.ConstLabels = map[string]string{"cluster": "a"}
+			c.Metric.Relabel = []relabelRule{{Action: RenameRelabelAction, Label: "ifname", TargetLabel: "interface"}}
+		}},
+		{
+			name:      "constant label that is a built-in label",
+			configure: func(c *Config) { c.Metric.ConstLabels = map[string]string{"ifname": "x"} },
+			wantErr:   "metric constLabels label 'ifname' is already used by built-in metrics",
+		},
+		{
+			name:      "constant inventory label without an inventory",
+			configure: func(c *Config) { c.Metric.ConstLabels = map[string]string{"site": "hq"} },
+		},
+		{
+			name: "constant inventory label with inventory overrides",
+			configure: func(c *Config) {
+				c.Metric.ConstLabels = map[string]string{"site": "hq"}
+				c.Enrichment.Inventory.OverrideLabels = true
+			},
+			wantErr: "metric constLabels label 'site' is already used by built-in metrics",
+		},
+		{
+			name: "constant inventory label with an inventory",
+			configure: func(c *Config) {
+				c.Metric.ConstLabels = map[string]string{"site": "hq"}
+				c.Enrichment.Inventory.Path = "inventory.csv"
+			},
+			wantErr: "metric constLabels label 'site' is already used by built-in metrics",
+		},
+		{
+			name:      "constant pool label without pools",
+			configure: func(c *Config) { c.Metric.ConstLabels = map[string]string{"pool": "a"} },
+		},
+		{
+			name: "constant pool label with the host pool label",
+			configure: func(c *Config) {
+				c.Metric.ConstLabels = map[string]string{"pool": "a"}
+				c.Metric.HostPoolLabel = true
+			},
+			wantErr: "metric constLabels label 'pool' is already used by built-in metrics",
+		},
+		{
+			name: "constant pool label with the pools scrape target",
+			configure: func(c *Config) {
+				c.Metric.ConstLabels = map[string]string{"pool": "a"}
+				c.Ntopng.ScrapeTargets = []string{AllScrape}
+			},
+			wantErr: "metric constLabels label 'pool' is already used by built-in metrics",
+		},
+		{
+			name: "constant label that is a custom host field key label",
+			configure: func(c *Config) {
+				c.Metric.ConstLabels = map[string]string{"protocol": "x"}
+				c.Metric.CustomHostFields = []customHostField{{Name: "ndpi", KeyLabels: []string{"protocol"}}}
+			},
+			wantErr: "metric constLabels label 'protocol' is already used by custom host field 'ndpi'",
+		},
+		{
+			name: "constant label that is a custom endpoint label",
+			configure: func(c *Config) {
+				c.Metric.ConstLabels = map[string]string{"alert_type": "x"}
+				c.Metric.CustomEndpoints = []customEndpoint{{Name: "alert", Metrics: []customEndpointMetric{{Name: "engaged",
+					Labels: map[string]string{"alert_type": "label"}}}}}
+			},
+			wantErr: "metric constLabels label 'alert_type' is already used by custom endpoint 'alert' metric 'engaged'",
+		},
+		{
+			name: "rename to a built-in label",
+			configure: func(c *Config) {
+				c.Metric.Relabel = []relabelRule{{Action: RenameRelabelAction, Label: "ifname", TargetLabel: "ip"}}
+			},
+			wantErr: "relabel rule 1 targetLabel 'ip' is already used by built-in metrics",
+		},
+		{
+			name: "rename to a constant label",
+			configure: func(c *Config) {
+				c.Metric.ConstLabels = map[string]string{"cluster": "a"}
+				c.Metric.Relabel = []relabelRule{{Action: RenameRelabelAction, Label: "ifname", TargetLabel: "cluster"}}
+			},
+			wantErr: "relabel rule 1 targetLabel 'cluster' is already used by metric constLabels",
+		},
+		{
+			name: "two renames to the same label",
+			configure: func(c *Config) {
+				c.Metric.Relabel = []relabelRule{{Action: RenameRelabelAction, Label: "ifname", TargetLabel: "interface"},
+					{Action: RenameRelabelAction, Label: "ifid", TargetLabel: "interface"}}
+			},
+			wantErr: "relabel rule 2 targetLabel 'interface' is already used by relabel rule 1",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := &Config{}
+			tt.configure(c)
+			constLabelNames := make([]string, 0, len(c.Metric.ConstLabels))
+			for label := range c.Metric.ConstLabels {
+				constLabelNames = append(constLabelNames, label)
+			}
+			err := c.Metric.validateLabelCollisions(constLabelNames, c.reservedLabelNames())
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("validateLabelCollisions() returned error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("validateLabelCollisions() error = %v, want an error containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,87 @@
+package enrichment
+
+import (
+	"fmt"
+	"net"
+	"strconv"
+)
+
+// GeoInfo holds everything that the configured GeoIP databases know about an IP, fields are left empty when no
+// database has an answer
+type GeoInfo struct {
+	ASN     string
+	ASOrg   string
+	City    string
+	Country string
+}
+
+// GeoIP performs lookups against any combination of the GeoLite2 / GeoIP2 Country, City and ASN databases
+type GeoIP struct {
+	asn     *mmdbReader
+	city    *mmdbReader
+	country *mmdbReader
+}
+
+// NewGeoIP opens every database path that is not empty, at least one path must be given
+func NewGeoIP(countryPath string, cityPath string, asnPath string) (*GeoIP, error) {
+	var geoIP GeoIP
+	var err error
+	if countryPath != "" {
+		if geoIP.country, err = openMMDB(countryPath); err != nil {
+			return nil, fmt.Errorf("failed to open country database: %v", err)
+		}
+	}
+	if cityPath != "" {
+		if geoIP.city, err = openMMDB(cityPath); err != nil {
+			return nil, fmt.Errorf("failed to open city database: %v", err)
+		}
+	}
+	if asnPath != "" {
+		if geoIP.asn, err = openMMDB(asnPath); err != nil {
+			return nil, fmt.Errorf("failed to open ASN database: %v", err)
+		}
+	}
+	if geoIP.country == nil && geoIP.city == nil && geoIP.asn == nil {
+		return nil, fmt.Errorf("no GeoIP databases were configured")
+	}
+	return &geoIP, nil
+}
+
+// Lookup returns the geographic and AS attribution of an IP. Errors from an individual database are returned, but any
+// information that could be found is still filled in.
+func (g *GeoIP) Lookup(ip string) (GeoInfo, error) {
+	var info GeoInfo
+	parsedIP := net.ParseIP(ip)
+	if parsedIP == nil {
+		return info, fmt.Errorf("'%s' is not a valid IP", ip)
+	}
+	var lookupErr error
+	if g.city != nil {
+		record, err := g.city.lookup(parsedIP)
+		if err != nil {
+			lookupErr = err
+		}
+		info.City, _ = mmdbPath(record, "city", "names", "en").(string)
+		info.Country, _ = mmdbPath(record, "country", "iso_code").(string)
+	}
+	if g.country != nil {
+		record, err := g.country.lookup(parsedIP)
+		if err != nil {
+			lookupErr = err
+		}
+		if country, ok := mmdbPath(record, "country", "iso_code").(string); ok {
+			info.Country = country
+		}
+	}
+	if g.asn != nil {
+		record, err := g.asn.lookup(parsedIP)
+		if err != nil {
+			lookupErr = err
+		}
+		if asn, ok := mmdbPath(record, "autonomous_system_number").(uint64); ok {
+			info.ASN = strconv.FormatUint(asn, 10)
+		}
+		info.ASOrg, _ = mmdbPath(record, "autonomous_system_organization").(string)
+	}
+	return info, lookupErr
+}
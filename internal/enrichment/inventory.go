@@ -0,0 +1,176 @@
+package enrichment
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aauren/ntopng-exporter/internal"
+	"github.com/spf13/viper"
+)
+
+// InventoryEntry is a single host from the static inventory file, it is keyed by either its IP or its MAC address
+type InventoryEntry struct {
+	IP         string `mapstructure:"ip"`
+	MAC        string `mapstructure:"mac"`
+	Owner      string `mapstructure:"owner"`
+	Hostname   string `mapstructure:"hostname"`
+	DeviceType string `mapstructure:"device_type"`
+	Site       string `mapstructure:"site"`
+}
+
+// Inventory holds the contents of a CSV or YAML inventory file and is able to reload it when it changes on disk
+type Inventory struct {
+	path    string
+	modTime time.Time
+	byIP    map[string]InventoryEntry
+	byMAC   map[string]InventoryEntry
+	mutex   sync.RWMutex
+}
+
+func NewInventory(path string) (*Inventory, error) {
+	inventory := &Inventory{path: path}
+	if _, err := inventory.ReloadIfChanged(); err != nil {
+		return nil, err
+	}
+	return inventory, nil
+}
+
+// ReloadIfChanged reads the inventory file again if its modification time has changed since it was last read. If the
+// file can't be parsed, the previously loaded inventory is kept and an error is returned.
+func (i *Inventory) ReloadIfChanged() (bool, error) {
+	info, err := os.Stat(i.path)
+	if err != nil {
+		return false, fmt.Errorf("was not able to stat inventory file: %v", err)
+	}
+	if info.ModTime().Equal(i.modTime) {
+		return false, nil
+	}
+	var entries []InventoryEntry
+	switch strings.ToLower(filepath.Ext(i.path)) {
+	case ".csv":
+		entries, err = readInventoryCSV(i.path)
+	case ".yaml", ".yml":
+		entries, err = readInventoryYAML(i.path)
+	default:
+		err = fmt.Errorf("inventory file must have a .csv, .yaml or .yml extension: %s", i.path)
+	}
+	if err != nil {
+		return false, err
+	}
+	byIP := make(map[string]InventoryEntry)
+	byMAC := make(map[string]InventoryEntry)
+	for _, entry := range entries {
+		if entry.IP == "" && entry.MAC == "" {
+			return false, fmt.Errorf("inventory entry must define either an ip or a mac: %v", entry)
+		}
+		if entry.IP != "" {
+			byIP[normalizeIP(entry.IP)] = entry
+		}
+		if entry.MAC != "" {
+			byMAC[internal.NormalizeMAC(entry.MAC)] = entry
+		}
+	}
+	i.mutex.Lock()
+	defer i.mutex.Unlock()
+	i.byIP = byIP
+	i.byMAC = byMAC
+	i.modTime = info.ModTime()
+	return true, nil
+}
+
+// Lookup finds the inventory entry for a host, matching on IP first and then on MAC address
+func (i *Inventory) Lookup(ip string, mac string) (InventoryEntry, bool) {
+	i.mutex.RLock()
+	defer i.mutex.RUnlock()
+	if entry, ok := i.byIP[normalizeIP(ip)]; ok {
+		return entry, true
+	}
+	if mac != "" {
+		if entry, ok := i.byMAC[internal.NormalizeMAC(mac)]; ok {
+			return entry, true
+		}
+	}
+	return InventoryEntry{}, false
+}
+
+// normalizeIP returns the canonical form of an IP address, so that e.g. IPv6 addresses written in upper case or
+// without their zeros compressed still match the form that ntopng reports them in. Anything that isn't an IP address
+// is returned as is.
+func normalizeIP(ip string) string {
+	if parsedIP := net.ParseIP(ip); parsedIP != nil {
+		return parsedIP.String()
+	}
+	return ip
+}
+
+// readInventoryCSV parses a CSV file whose first row is a header naming the columns, columns that don't correspond to
+// an InventoryEntry field are ignored
+func readInventoryCSV(path string) ([]InventoryEntry, error) {
+	file, err := os.Open(path) //nolint:gosec // path comes from trusted application configuration
+	if err != nil {
+		return nil, fmt.Errorf("was not able to open inventory file: %v", err)
+	}
+	defer file.Close()
+
+	reader := csv.NewReader(file)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("was not able to read header from inventory file: %v", err)
+	}
+	var entries []InventoryEntry
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("was not able to parse inventory file: %v", err)
+		}
+		var entry InventoryEntry
+		for idx, column := range header {
+			if idx >= len(record) {
+				break
+			}
+			value := strings.TrimSpace(record[idx])
+			switch strings.ToLower(strings.TrimSpace(column)) {
+			case "ip":
+				entry.IP = value
+			case "mac":
+				entry.MAC = value
+			case "owner":
+				entry.Owner = value
+			case "hostname":
+				entry.Hostname = value
+			case "device_type":
+				entry.DeviceType = value
+			case "site":
+				entry.Site = value
+			}
+		}
+		entries = append(entries, entry)
+	}
+	return entries, nil
+}
+
+// readInventoryYAML parses a YAML file containing a list of entries under a top level hosts key
+func readInventoryYAML(path string) ([]InventoryEntry, error) {
+	v := viper.New()
+	v.SetConfigFile(path)
+	if err := v.ReadInConfig(); err != nil {
+		return nil, fmt.Errorf("was not able to read inventory file: %v", err)
+	}
+	var entries []InventoryEntry
+	if err := v.UnmarshalKey("hosts", &entries); err != nil {
+		return nil, fmt.Errorf("was not able to parse hosts from inventory file: %v", err)
+	}
+	return entries, nil
+}
@@ -0,0 +1,137 @@
+package enrichment
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestInventoryLookup(t *testing.T) {
+	nas := InventoryEntry{IP: "192.168.0.10", Hostname: "nas", Owner: "ops", DeviceType: "storage", Site: "hq"}
+	laptop := InventoryEntry{MAC: "AA-BB-CC-00-00-01", Hostname: "laptop", Owner: "alice", DeviceType: "laptop",
+		Site: "hq"}
+	printer := InventoryEntry{IP: "2001:DB8::10", Hostname: "printer", Owner: "ops", DeviceType: "printer",
+		Site: "branch"}
+	camera := InventoryEntry{IP: "192.168.0.20", MAC: "aabb.cc00.0002", Hostname: "camera"}
+	lookups := []struct {
+		name      string
+		ip        string
+		mac       string
+		wantEntry InventoryEntry
+		wantFound bool
+	}{
+		{name: "by IP", ip: "192.168.0.10", mac: "AA:BB:CC:00:00:09", wantEntry: nas, wantFound: true},
+		{name: "by MAC with different separators and case", ip: "192.168.0.11", mac: "aa:bb:cc:00:00:01",
+			wantEntry: laptop, wantFound: true},
+		{name: "by IPv6 address in canonical form", ip: "2001:db8::10", wantEntry: printer, wantFound: true},
+		{name: "by expanded IPv6 address", ip: "2001:0db8:0000:0000:0000:0000:0000:0010", wantEntry: printer,
+			wantFound: true},
+		{name: "by MAC in dotted form", ip: "192.168.0.21", mac: "AA:BB:CC:00:00:02", wantEntry: camera,
+			wantFound: true},
+		{name: "IP takes precedence over MAC", ip: "192.168.0.10", mac: "AA:BB:CC:00:00:01", wantEntry: nas,
+			wantFound: true},
+		{name: "unknown host", ip: "192.168.0.99", mac: "AA:BB:CC:00:00:99"},
+		{name: "unknown host without a MAC", ip: "192.168.0.99"},
+	}
+	for _, fixture := range []string{"testdata/inventory.csv", "testdata/inventory.yaml"} {
+		t.Run(fixture, func(t *testing.T) {
+			inventory, err := NewInventory(fixture)
+			if err != nil {
+				t.Fatalf("NewInventory() returned error: %v", err)
+			}
+			for _, tt := range lookups {
+				t.Run(tt.name, func(t *testing.T) {
+					entry, found := inventory.Lookup(tt.ip, tt.mac)
+					if entry != tt.wantEntry || found != tt.wantFound {
+						t.Errorf("Lookup(%s, %s) = %+v, %t, want %+v, %t", tt.ip, tt.mac, entry, found, tt.wantEntry,
+							tt.wantFound)
+					}
+				})
+			}
+		})
+	}
+}
+
+func TestNewInventoryInvalid(t *testing.T) {
+	tests := []struct {
+		name    string
+		file    string
+		content string
+	}{
+		{name: "unsupported extension", file: "inventory.txt", content: "ip,hostname\n192.168.0.10,nas\n"},
+		{name: "entry without an ip or mac", file: "inventory.csv", content: "ip,mac,hostname\n,,nas\n"},
+		{name: "malformed CSV", file: "inventory.csv", content: "ip,hostname\n192.168.0.10,\"nas\n"},
+		{name: "empty CSV", file: "inventory.csv", content: ""},
+		{name: "malformed YAML", file: "inventory.yaml", content: "hosts:\n- ip: [192.168.0.10\n"},
+		{name: "YAML hosts that aren't a list", file: "inventory.yaml", content: "hosts: nas\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), tt.file)
+			if err := os.WriteFile(path, []byte(tt.content), 0o600); err != nil {
+				t.Fatalf("was not able to write %s: %v", path, err)
+			}
+			if _, err := NewInventory(path); err == nil {
+				t.Errorf("NewInventory() didn't return an error")
+			}
+		})
+	}
+	if _, err := NewInventory(filepath.Join(t.TempDir(), "missing.csv")); err == nil {
+		t.Errorf("NewInventory() of a missing file didn't return an error")
+	}
+}
+
+func TestInventoryReloadIfChanged(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "inventory.csv")
+	modTime := time.Now().Add(-time.Hour)
+	// write replaces the file and moves its modification time forward, as filesystems with a coarse timestamp
+	// resolution wouldn't otherwise see a change between writes within the same test
+	write := func(content string) {
+		t.Helper()
+		if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+			t.Fatalf("was not able to write %s: %v", path, err)
+		}
+		modTime = modTime.Add(time.Minute)
+		if err := os.Chtimes(path, modTime, modTime); err != nil {
+			t.Fatalf("was not able to change the modification time of %s: %v", path, err)
+		}
+	}
+
+	write("ip,hostname,site\n192.168.0.10,nas,hq\n")
+	inventory, err := NewInventory(path)
+	if err != nil {
+		t.Fatalf("NewInventory() returned error: %v", err)
+	}
+	assertHostname := func(want string) {
+		t.Helper()
+		if entry, _ := inventory.Lookup("192.168.0.10", ""); entry.Hostname != want {
+			t.Errorf("Lookup() hostname = %q, want %q", entry.Hostname, want)
+		}
+	}
+	assertHostname("nas")
+	if reloaded, err := inventory.ReloadIfChanged(); reloaded || err != nil {
+		t.Errorf("ReloadIfChanged() of an unchanged file = %t, %v, want false, nil", reloaded, err)
+	}
+
+	write("ip,hostname,site\n192.168.0.10,storage,branch\n")
+	if reloaded, err := inventory.ReloadIfChanged(); !reloaded || err != nil {
+		t.Fatalf("ReloadIfChanged() of a changed file = %t, %v, want true, nil", reloaded, err)
+	}
+	assertHostname("storage")
+	if entry, _ := inventory.Lookup("192.168.0.10", ""); entry.Site != "branch" {
+		t.Errorf("Lookup() site = %q, want %q", entry.Site, "branch")
+	}
+
+	// A file that can't be parsed keeps the previous inventory, and is tried again when it changes
+	write("ip,hostname,site\n192.168.0.10,\"broken\n")
+	if reloaded, err := inventory.ReloadIfChanged(); reloaded || err == nil {
+		t.Errorf("ReloadIfChanged() of a malformed file = %t, %v, want false and an error", reloaded, err)
+	}
+	assertHostname("storage")
+	write("ip,hostname,site\n192.168.0.10,fixed,hq\n")
+	if reloaded, err := inventory.ReloadIfChanged(); !reloaded || err != nil {
+		t.Fatalf("ReloadIfChanged() of a fixed file = %t, %v, want true, nil", reloaded, err)
+	}
+	assertHostname("fixed")
+}
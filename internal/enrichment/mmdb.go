@@ -0,0 +1,343 @@
+package enrichment
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"math"
+	"math/big"
+	"net"
+	"os"
+)
+
+// This file implements the small subset of the MaxMind DB format (https://maxmind.github.io/MaxMind-DB/) that is
+// needed to do IP lookups against the GeoLite2 databases without bringing in an additional dependency.
+
+const (
+	mmdbDataSectionSeparatorSize = 16
+	mmdbIPv4BitCount             = 32
+	mmdbIPv6BitCount             = 128
+	// mmdbMaxMetadataSize is how far from the end of the file we search for the metadata marker
+	mmdbMaxMetadataSize = 128 * 1024
+	// mmdbMaxDecodeDepth bounds how deeply maps, arrays and pointers can be nested, so that a malformed database with
+	// pointers that loop back onto themselves returns an error rather than overflowing the stack
+	mmdbMaxDecodeDepth = 512
+)
+
+const (
+	mmdbExtended = iota
+	mmdbPointer
+	mmdbString
+	mmdbDouble
+	mmdbBytes
+	mmdbUint16
+	mmdbUint32
+	mmdbMap
+	mmdbInt32
+	mmdbUint64
+	mmdbUint128
+	mmdbArray
+	mmdbContainer
+	mmdbEndMarker
+	mmdbBool
+	mmdbFloat
+)
+
+var mmdbMetadataStart = []byte("\xAB\xCD\xEFMaxMind.com")
+
+type mmdbReader struct {
+	buffer     []byte
+	data       []byte
+	nodeCount  uint
+	recordSize uint
+	ipVersion  uint
+	ipv4Start  uint
+	dbType     string
+}
+
+func openMMDB(path string) (*mmdbReader, error) {
+	buffer, err := os.ReadFile(path) //nolint:gosec // path comes from trusted application configuration
+	if err != nil {
+		return nil, fmt.Errorf("was not able to read MaxMind database: %v", err)
+	}
+	searchStart := 0
+	if len(buffer) > mmdbMaxMetadataSize {
+		searchStart = len(buffer) - mmdbMaxMetadataSize
+	}
+	metadataStart := bytes.LastIndex(buffer[searchStart:], mmdbMetadataStart)
+	if metadataStart < 0 {
+		return nil, fmt.Errorf("%s does not appear to be a MaxMind database, no metadata found", path)
+	}
+	metadataStart += searchStart + len(mmdbMetadataStart)
+	metadata, _, err := (&mmdbDecoder{buffer: buffer[metadataStart:]}).decode(0)
+	if err != nil {
+		return nil, fmt.Errorf("was not able to decode MaxMind database metadata: %v", err)
+	}
+	metadataMap, ok := metadata.(map[string]any)
+	if !ok {
+		return nil, fmt.Errorf("MaxMind database metadata was not a map")
+	}
+	reader := &mmdbReader{buffer: buffer}
+	reader.nodeCount = mmdbUint(metadataMap["node_count"])
+	reader.recordSize = mmdbUint(metadataMap["record_size"])
+	reader.ipVersion = mmdbUint(metadataMap["ip_version"])
+	reader.dbType, _ = metadataMap["database_type"].(string)
+	if reader.recordSize != 24 && reader.recordSize != 28 && reader.recordSize != 32 {
+		return nil, fmt.Errorf("unsupported MaxMind database record size: %d", reader.recordSize)
+	}
+	searchTreeSize := reader.nodeCount * reader.recordSize / 4
+	dataStart := searchTreeSize + mmdbDataSectionSeparatorSize
+	if dataStart > uint(metadataStart) {
+		return nil, fmt.Errorf("MaxMind database search tree is larger than the database")
+	}
+	reader.data = buffer[dataStart:metadataStart]
+
+	// IPv4 addresses live at ::/96 in an IPv6 tree, so we find that node once and start all IPv4 lookups from there
+	if reader.ipVersion == 6 {
+		node := uint(0)
+		for i := 0; i < mmdbIPv6BitCount-mmdbIPv4BitCount && node < reader.nodeCount; i++ {
+			node = reader.readRecord(node, 0)
+		}
+		reader.ipv4Start = node
+	}
+	return reader, nil
+}
+
+// lookup returns the decoded data record for the IP, or nil if the IP is not contained in the database
+func (r *mmdbReader) lookup(ip net.IP) (any, error) {
+	node, bitCount := uint(0), mmdbIPv6BitCount
+	if ipv4 := ip.To4(); ipv4 != nil {
+		ip, bitCount, node = ipv4, mmdbIPv4BitCount, r.ipv4Start
+	} else if r.ipVersion == 4 {
+		return nil, nil
+	}
+	for i := 0; i < bitCount && node < r.nodeCount; i++ {
+		bit := uint(ip[i>>3]>>(7-(i%8))) & 1
+		node = r.readRecord(node, bit)
+	}
+	if node == r.nodeCount {
+		return nil, nil
+	}
+	if node < r.nodeCount {
+		return nil, fmt.Errorf("invalid MaxMind database search tree for IP: %s", ip)
+	}
+	offset := node - r.nodeCount - mmdbDataSectionSeparatorSize
+	if offset >= uint(len(r.data)) {
+		return nil, fmt.Errorf("MaxMind database record pointer for IP %s is outside of the data section", ip)
+	}
+	record, _, err := (&mmdbDecoder{buffer: r.data}).decode(offset)
+	return record, err
+}
+
+// readRecord returns the left (bit 0) or right (bit 1) record of the given search tree node
+func (r *mmdbReader) readRecord(node uint, bit uint) uint {
+	nodeSize := r.recordSize / 4
+	b := r.buffer[node*nodeSize : (node+1)*nodeSize]
+	switch r.recordSize {
+	case 24:
+		if bit == 0 {
+			return uint(b[0])<<16 | uint(b[1])<<8 | uint(b[2])
+		}
+		return uint(b[3])<<16 | uint(b[4])<<8 | uint(b[5])
+	case 28:
+		if bit == 0 {
+			return (uint(b[3])&0xF0)<<20 | uint(b[0])<<16 | uint(b[1])<<8 | uint(b[2])
+		}
+		return (uint(b[3])&0x0F)<<24 | uint(b[4])<<16 | uint(b[5])<<8 | uint(b[6])
+	default:
+		if bit == 0 {
+			return uint(binary.BigEndian.Uint32(b[0:4]))
+		}
+		return uint(binary.BigEndian.Uint32(b[4:8]))
+	}
+}
+
+type mmdbDecoder struct {
+	buffer []byte
+	// depth is the number of maps, arrays and pointers that the field currently being decoded is nested within
+	depth int
+}
+
+// decode decodes the field at offset into native go types and returns it along with the offset of the next field
+func (d *mmdbDecoder) decode(offset uint) (any, uint, error) {
+	if d.depth >= mmdbMaxDecodeDepth {
+		return nil, 0, fmt.Errorf("MaxMind database data is nested more than %d levels deep", mmdbMaxDecodeDepth)
+	}
+	d.depth++
+	defer func() { d.depth-- }()
+	if offset >= uint(len(d.buffer)) {
+		return nil, 0, fmt.Errorf("unexpected end of MaxMind database data")
+	}
+	ctrl := d.buffer[offset]
+	offset++
+	dataType := uint(ctrl >> 5)
+	if dataType == mmdbPointer {
+		pointer, newOffset, err := d.decodePointer(ctrl, offset)
+		if err != nil {
+			return nil, 0, err
+		}
+		// The format doesn't allow pointers to point at other pointers
+		if pointer < uint(len(d.buffer)) && uint(d.buffer[pointer]>>5) == mmdbPointer {
+			return nil, 0, fmt.Errorf("MaxMind database pointer at %d points to another pointer", offset-1)
+		}
+		value, _, err := d.decode(pointer)
+		return value, newOffset, err
+	}
+	if dataType == mmdbExtended {
+		if offset >= uint(len(d.buffer)) {
+			return nil, 0, fmt.Errorf("unexpected end of MaxMind database data")
+		}
+		dataType = 7 + uint(d.buffer[offset])
+		offset++
+	}
+	size, offset, err := d.decodeSize(ctrl, offset)
+	if err != nil {
+		return nil, 0, err
+	}
+	switch dataType {
+	case mmdbMap:
+		return d.decodeMap(size, offset)
+	case mmdbArray:
+		return d.decodeArray(size, offset)
+	case mmdbBool:
+		return size != 0, offset, nil
+	}
+	if offset+size > uint(len(d.buffer)) {
+		return nil, 0, fmt.Errorf("unexpected end of MaxMind database data")
+	}
+	b := d.buffer[offset : offset+size]
+	newOffset := offset + size
+	switch dataType {
+	case mmdbString:
+		return string(b), newOffset, nil
+	case mmdbBytes:
+		return append([]byte(nil), b...), newOffset, nil
+	case mmdbDouble:
+		if size != 8 {
+			return nil, 0, fmt.Errorf("invalid size for MaxMind double: %d", size)
+		}
+		return math.Float64frombits(binary.BigEndian.Uint64(b)), newOffset, nil
+	case mmdbFloat:
+		if size != 4 {
+			return nil, 0, fmt.Errorf("invalid size for MaxMind float: %d", size)
+		}
+		return float64(math.Float32frombits(binary.BigEndian.Uint32(b))), newOffset, nil
+	case mmdbUint16, mmdbUint32, mmdbUint64:
+		var value uint64
+		for _, octet := range b {
+			value = value<<8 | uint64(octet)
+		}
+		return value, newOffset, nil
+	case mmdbInt32:
+		var value uint32
+		for _, octet := range b {
+			value = value<<8 | uint32(octet)
+		}
+		return int64(int32(value)), newOffset, nil
+	case mmdbUint128:
+		return new(big.Int).SetBytes(b), newOffset, nil
+	default:
+		return nil, 0, fmt.Errorf("unsupported MaxMind data type: %d", dataType)
+	}
+}
+
+func (d *mmdbDecoder) decodePointer(ctrl byte, offset uint) (uint, uint, error) {
+	pointerSize := uint((ctrl>>3)&0x3) + 1
+	if offset+pointerSize > uint(len(d.buffer)) {
+		return 0, 0, fmt.Errorf("unexpected end of MaxMind database data")
+	}
+	b := d.buffer[offset : offset+pointerSize]
+	var prefix uint
+	if pointerSize != 4 {
+		prefix = uint(ctrl & 0x7)
+	}
+	pointer := prefix
+	for _, octet := range b {
+		pointer = pointer<<8 | uint(octet)
+	}
+	switch pointerSize {
+	case 2:
+		pointer += 2048
+	case 3:
+		pointer += 526336
+	}
+	return pointer, offset + pointerSize, nil
+}
+
+func (d *mmdbDecoder) decodeSize(ctrl byte, offset uint) (uint, uint, error) {
+	size := uint(ctrl & 0x1f)
+	if size < 29 {
+		return size, offset, nil
+	}
+	extraBytes := size - 28
+	if offset+extraBytes > uint(len(d.buffer)) {
+		return 0, 0, fmt.Errorf("unexpected end of MaxMind database data")
+	}
+	var value uint
+	for _, octet := range d.buffer[offset : offset+extraBytes] {
+		value = value<<8 | uint(octet)
+	}
+	switch size {
+	case 29:
+		size = 29 + value
+	case 30:
+		size = 285 + value
+	default:
+		size = 65821 + value
+	}
+	return size, offset + extraBytes, nil
+}
+
+func (d *mmdbDecoder) decodeMap(size uint, offset uint) (any, uint, error) {
+	result := make(map[string]any, size)
+	for i := uint(0); i < size; i++ {
+		key, newOffset, err := d.decode(offset)
+		if err != nil {
+			return nil, 0, err
+		}
+		keyString, ok := key.(string)
+		if !ok {
+			return nil, 0, fmt.Errorf("MaxMind map key was not a string: %v", key)
+		}
+		value, newOffset, err := d.decode(newOffset)
+		if err != nil {
+			return nil, 0, err
+		}
+		result[keyString] = value
+		offset = newOffset
+	}
+	return result, offset, nil
+}
+
+func (d *mmdbDecoder) decodeArray(size uint, offset uint) (any, uint, error) {
+	result := make([]any, 0, size)
+	for i := uint(0); i < size; i++ {
+		value, newOffset, err := d.decode(offset)
+		if err != nil {
+			return nil, 0, err
+		}
+		result = append(result, value)
+		offset = newOffset
+	}
+	return result, offset, nil
+}
+
+// mmdbUint converts a decoded unsigned integer into a uint, returning 0 for any other type
+func mmdbUint(value any) uint {
+	if v, ok := value.(uint64); ok {
+		return uint(v)
+	}
+	return 0
+}
+
+// mmdbPath walks a decoded record through nested maps by key, returning nil if any part of the path doesn't exist
+func mmdbPath(record any, keys ...string) any {
+	for _, key := range keys {
+		recordMap, ok := record.(map[string]any)
+		if !ok {
+			return nil
+		}
+		record = recordMap[key]
+	}
+	return record
+}
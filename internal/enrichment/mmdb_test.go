@@ -0,0 +1,117 @@
+package enrichment
+
+import (
+	"net"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// testdata/city.mmdb contains 8.8.8.0/24 and 2001:db8::/32 in an IPv6 search tree, both of which point at a shared
+// country map. It is written by testdata/gen_city_mmdb.go, which lists every record that it contains
+//
+//go:generate go run testdata/gen_city_mmdb.go
+const testCityDatabase = "testdata/city.mmdb"
+
+func TestMMDBLookup(t *testing.T) {
+	reader, err := openMMDB(testCityDatabase)
+	if err != nil {
+		t.Fatalf("openMMDB() returned error: %v", err)
+	}
+	if reader.dbType != "GeoLite2-City" {
+		t.Errorf("dbType = %q, want GeoLite2-City", reader.dbType)
+	}
+	tests := []struct {
+		name string
+		ip   string
+		path []string
+		want any
+	}{
+		{name: "IPv4 in IPv6 tree", ip: "8.8.8.8", path: []string{"city", "names", "en"}, want: "Mountain View"},
+		{name: "IPv4 mapped IPv6 address", ip: "::ffff:8.8.8.8", path: []string{"city", "names", "en"}, want: "Mountain View"},
+		{name: "IPv6", ip: "2001:db8::1", path: []string{"city", "names", "en"}, want: "Documentation"},
+		{name: "pointer from IPv4 record", ip: "8.8.8.8", path: []string{"country", "iso_code"}, want: "US"},
+		{name: "pointer from IPv6 record", ip: "2001:db8::1", path: []string{"country", "iso_code"}, want: "US"},
+		{
+			name: "array of maps",
+			ip:   "8.8.8.8",
+			path: []string{"subdivisions"},
+			want: []any{map[string]any{"iso_code": "CA"}, map[string]any{"iso_code": "SC"}},
+		},
+		{name: "double", ip: "8.8.8.8", path: []string{"location", "latitude"}, want: 37.386},
+		{name: "bool", ip: "8.8.8.8", path: []string{"is_anycast"}, want: true},
+		{name: "uint32", ip: "8.8.8.8", path: []string{"autonomous_system_number"}, want: uint64(15169)},
+		{name: "long string", ip: "8.8.8.8", path: []string{"autonomous_system_organization"}, want: "GOOGLE"},
+		{name: "missing key", ip: "8.8.8.8", path: []string{"postal", "code"}, want: nil},
+		{name: "IPv4 not in database", ip: "1.1.1.1", path: nil, want: nil},
+		{name: "IPv6 not in database", ip: "2001:db9::1", path: nil, want: nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			record, err := reader.lookup(net.ParseIP(tt.ip))
+			if err != nil {
+				t.Fatalf("lookup(%s) returned error: %v", tt.ip, err)
+			}
+			if got := mmdbPath(record, tt.path...); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("lookup(%s) at %v = %#v, want %#v", tt.ip, tt.path, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestOpenMMDBTruncated(t *testing.T) {
+	database, err := os.ReadFile(testCityDatabase)
+	if err != nil {
+		t.Fatalf("was not able to read %s: %v", testCityDatabase, err)
+	}
+	tests := []struct {
+		name   string
+		length int
+	}{
+		{name: "empty", length: 0},
+		{name: "search tree only", length: 64},
+		{name: "metadata cut off", length: len(database) - 10},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "truncated.mmdb")
+			if err := os.WriteFile(path, database[:tt.length], 0o600); err != nil {
+				t.Fatalf("was not able to write %s: %v", path, err)
+			}
+			if _, err := openMMDB(path); err == nil {
+				t.Errorf("openMMDB() of a database truncated to %d bytes didn't return an error", tt.length)
+			}
+		})
+	}
+}
+
+func TestMMDBDecodeMalformed(t *testing.T) {
+	deepArrays := make([]byte, 0, 2*(mmdbMaxDecodeDepth+1))
+	for i := 0; i <= mmdbMaxDecodeDepth; i++ {
+		deepArrays = append(deepArrays, 0x01, 0x04)
+	}
+	tests := []struct {
+		name   string
+		buffer []byte
+	}{
+		{name: "empty", buffer: []byte{}},
+		{name: "pointer to itself", buffer: []byte{0x20, 0x00}},
+		{name: "pointer to another pointer", buffer: []byte{0x20, 0x02, 0x20, 0x00}},
+		{name: "array containing a pointer to itself", buffer: []byte{0x01, 0x04, 0x20, 0x00}},
+		{name: "map containing a pointer to itself", buffer: []byte{0xe1, 0x41, 'a', 0x20, 0x00}},
+		{name: "nested too deeply", buffer: deepArrays},
+		{name: "truncated string", buffer: []byte{0x45, 'a', 'b'}},
+		{name: "truncated pointer", buffer: []byte{0x28, 0x00}},
+		{name: "truncated extended type", buffer: []byte{0x01}},
+		{name: "truncated size", buffer: []byte{0x5d}},
+		{name: "map with non string key", buffer: []byte{0xe1, 0xc1, 0x01, 0x41, 'a'}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if value, _, err := (&mmdbDecoder{buffer: tt.buffer}).decode(0); err == nil {
+				t.Errorf("decode(%x) = %#v, want an error", tt.buffer, value)
+			}
+		})
+	}
+}
@@ -0,0 +1,128 @@
+package enrichment
+
+import (
+	"bufio"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/aauren/ntopng-exporter/internal"
+)
+
+const (
+	// ouiPrefixBits is the size of a standard (MA-L) assignment, used when a manuf entry doesn't specify a mask
+	ouiPrefixBits   = 24
+	bitsPerHexDigit = 4
+)
+
+// OUIDatabase maps MAC address prefixes to the vendor that they are assigned to
+type OUIDatabase struct {
+	// vendors is keyed by the normalized hex digits of the prefix
+	vendors map[string]string
+	// prefixLengths holds the number of hex digits of all of the prefixes in vendors, longest first
+	prefixLengths []int
+}
+
+// NewOUIDatabase loads either an IEEE registry CSV file (oui.csv, mam.csv, oui36.csv), identified by its .csv
+// extension, or a Wireshark manuf file
+func NewOUIDatabase(path string) (*OUIDatabase, error) {
+	file, err := os.Open(path) //nolint:gosec // path comes from trusted application configuration
+	if err != nil {
+		return nil, fmt.Errorf("was not able to open OUI database: %v", err)
+	}
+	defer file.Close()
+
+	db := &OUIDatabase{vendors: make(map[string]string)}
+	if strings.ToLower(filepath.Ext(path)) == ".csv" {
+		err = db.readIEEE(file)
+	} else {
+		err = db.readManuf(file)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if len(db.vendors) < 1 {
+		return nil, fmt.Errorf("OUI database did not contain any entries: %s", path)
+	}
+	lengths := make(map[int]bool)
+	for prefix := range db.vendors {
+		lengths[len(prefix)] = true
+	}
+	for length := range lengths {
+		db.prefixLengths = append(db.prefixLengths, length)
+	}
+	sort.Sort(sort.Reverse(sort.IntSlice(db.prefixLengths)))
+	return db, nil
+}
+
+// Lookup returns the vendor of the longest prefix matching the MAC address, or an empty string if there is none
+func (db *OUIDatabase) Lookup(mac string) string {
+	normalizedMAC := internal.NormalizeMAC(mac)
+	for _, length := range db.prefixLengths {
+		if len(normalizedMAC) < length {
+			continue
+		}
+		if vendor, ok := db.vendors[normalizedMAC[:length]]; ok {
+			return vendor
+		}
+	}
+	return ""
+}
+
+// readIEEE parses the IEEE registry format: Registry,Assignment,Organization Name,Organization Address
+func (db *OUIDatabase) readIEEE(reader io.Reader) error {
+	csvReader := csv.NewReader(reader)
+	csvReader.FieldsPerRecord = -1
+	csvReader.LazyQuotes = true
+	records, err := csvReader.ReadAll()
+	if err != nil {
+		return fmt.Errorf("was not able to parse IEEE OUI database: %v", err)
+	}
+	for idx, record := range records {
+		// Skip the header row
+		if idx == 0 || len(record) < 3 {
+			continue
+		}
+		db.vendors[internal.NormalizeMAC(strings.TrimSpace(record[1]))] = strings.TrimSpace(record[2])
+	}
+	return nil
+}
+
+// readManuf parses the Wireshark manuf format: <prefix>[/<mask bits>]<tab><short name>[<tab><long name>]
+func (db *OUIDatabase) readManuf(reader io.Reader) error {
+	scanner := bufio.NewScanner(reader)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		fields := strings.Split(line, "\t")
+		if len(fields) < 2 {
+			continue
+		}
+		prefix, maskBits := fields[0], ouiPrefixBits
+		if before, after, found := strings.Cut(prefix, "/"); found {
+			bits, err := strconv.Atoi(after)
+			if err != nil {
+				return fmt.Errorf("was not able to parse mask of manuf entry '%s': %v", fields[0], err)
+			}
+			prefix, maskBits = before, bits
+		}
+		normalizedPrefix := internal.NormalizeMAC(prefix)
+		if hexDigits := maskBits / bitsPerHexDigit; hexDigits < len(normalizedPrefix) {
+			normalizedPrefix = normalizedPrefix[:hexDigits]
+		}
+		// Older manuf files put the long name in a trailing comment
+		vendor := strings.TrimSpace(strings.TrimPrefix(fields[len(fields)-1], "#"))
+		db.vendors[normalizedPrefix] = vendor
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("was not able to read manuf OUI database: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,85 @@
+package enrichment
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestOUIDatabaseLookup(t *testing.T) {
+	tests := []struct {
+		name    string
+		fixture string
+		lookups map[string]string
+	}{
+		{
+			name:    "IEEE registry",
+			fixture: "testdata/oui.csv",
+			lookups: map[string]string{
+				"00:00:0c:12:34:56": "Cisco Systems, Inc",
+				"70:B3:D5:F0:00:01": "IEEE Registration Authority",
+				"70-B3-D5-A9-00-01": "Example MA-M Vendor",
+				"70b3.d5a1.2345":    "Example MA-S Vendor",
+				"70B3D5A13456":      "Example MA-M Vendor",
+				"00:00:01:00:00:01": "",
+				"70:b3":             "",
+				"":                  "",
+			},
+		},
+		{
+			name:    "Wireshark manuf",
+			fixture: "testdata/manuf",
+			lookups: map[string]string{
+				"00:00:01:00:00:01": "Xerox",
+				"00:00:0c:12:34:56": "Cisco Systems, Inc",
+				"08:00:2b:00:00:01": "Digital Equipment Corporation",
+				"70:B3:D5:F0:00:01": "IEEE Registration Authority",
+				"70-B3-D5-A9-00-01": "Example MA-M Vendor",
+				"70b3.d5a1.2345":    "Example MA-S Vendor",
+				"70B3D5A13456":      "Example MA-M Vendor",
+				"00:00:02:00:00:01": "",
+				"":                  "",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, err := NewOUIDatabase(tt.fixture)
+			if err != nil {
+				t.Fatalf("NewOUIDatabase() returned error: %v", err)
+			}
+			for mac, want := range tt.lookups {
+				if vendor := db.Lookup(mac); vendor != want {
+					t.Errorf("Lookup(%q) = %q, want %q", mac, vendor, want)
+				}
+			}
+		})
+	}
+}
+
+func TestNewOUIDatabaseInvalid(t *testing.T) {
+	tests := []struct {
+		name    string
+		file    string
+		content string
+	}{
+		{name: "IEEE registry without entries", file: "oui.csv",
+			content: "Registry,Assignment,Organization Name,Organization Address\n"},
+		{name: "manuf without entries", file: "manuf", content: "# only a comment\n\n"},
+		{name: "manuf with a malformed mask", file: "manuf", content: "70:B3:D5:A0:00:00/x\tExample\tExample Vendor\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), tt.file)
+			if err := os.WriteFile(path, []byte(tt.content), 0o600); err != nil {
+				t.Fatalf("was not able to write %s: %v", path, err)
+			}
+			if _, err := NewOUIDatabase(path); err == nil {
+				t.Errorf("NewOUIDatabase() didn't return an error")
+			}
+		})
+	}
+	if _, err := NewOUIDatabase(filepath.Join(t.TempDir(), "missing.csv")); err == nil {
+		t.Errorf("NewOUIDatabase() of a missing file didn't return an error")
+	}
+}
@@ -0,0 +1,161 @@
+package enrichment
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+	"time"
+)
+
+const rdnsQueueSize = 1024
+
+// addrResolver is the part of net.Resolver that ReverseDNS uses, so that tests can swap in a resolver that doesn't
+// depend on the network
+type addrResolver interface {
+	LookupAddr(ctx context.Context, addr string) ([]string, error)
+}
+
+type rdnsCacheEntry struct {
+	name    string
+	expires time.Time
+}
+
+// ReverseDNS resolves host names with PTR lookups in the background. Lookup never blocks, it answers from the cache
+// and queues a lookup for any IP that is missing or expired, so that names show up in later scrapes.
+type ReverseDNS struct {
+	resolver     addrResolver
+	subnets      []*net.IPNet
+	cacheTTL     time.Duration
+	negativeTTL  time.Duration
+	timeout      time.Duration
+	lookupPeriod time.Duration
+	cache        map[string]rdnsCacheEntry
+	pending      map[string]bool
+	queue        chan string
+	lastCleanup  time.Time
+	cacheRWMutex sync.RWMutex
+	pendingMutex sync.Mutex
+}
+
+// NewReverseDNS creates a resolver for hosts within the given subnets. If server is empty the system resolver is used,
+// otherwise all queries are sent to server (host:port).
+func NewReverseDNS(subnets []string, server string, cacheTTL time.Duration, negativeTTL time.Duration,
+	timeout time.Duration, lookupsPerSecond int) (*ReverseDNS, error) {
+	if lookupsPerSecond < 1 {
+		return nil, fmt.Errorf("lookups per second must be at least 1")
+	}
+	rdns := &ReverseDNS{
+		resolver:     net.DefaultResolver,
+		cacheTTL:     cacheTTL,
+		negativeTTL:  negativeTTL,
+		timeout:      timeout,
+		lookupPeriod: time.Second / time.Duration(lookupsPerSecond),
+		cache:        make(map[string]rdnsCacheEntry),
+		pending:      make(map[string]bool),
+		queue:        make(chan string, rdnsQueueSize),
+	}
+	for _, subnet := range subnets {
+		_, parsedSubnet, err := net.ParseCIDR(subnet)
+		if err != nil {
+			return nil, fmt.Errorf("subnet specified: '%s', is not a valid subnet: %v", subnet, err)
+		}
+		rdns.subnets = append(rdns.subnets, parsedSubnet)
+	}
+	if server != "" {
+		rdns.resolver = &net.Resolver{
+			PreferGo: true,
+			Dial: func(ctx context.Context, network, _ string) (net.Conn, error) {
+				var dialer net.Dialer
+				return dialer.DialContext(ctx, network, server)
+			},
+		}
+	}
+	return rdns, nil
+}
+
+// Run works through the lookup queue at the configured rate until stopChan is closed
+func (r *ReverseDNS) Run(stopChan <-chan struct{}) {
+	ticker := time.NewTicker(r.lookupPeriod)
+	defer ticker.Stop()
+	for {
+		select {
+		case ip := <-r.queue:
+			select {
+			case <-ticker.C:
+			case <-stopChan:
+				return
+			}
+			r.resolve(ip)
+		case <-stopChan:
+			return
+		}
+	}
+}
+
+// Lookup returns the cached name for the IP, or an empty string if there isn't one yet. Stale names continue to be
+// returned while they are being refreshed.
+func (r *ReverseDNS) Lookup(ip string) string {
+	parsedIP := net.ParseIP(ip)
+	if parsedIP == nil || !r.inSubnets(parsedIP) {
+		return ""
+	}
+	r.cacheRWMutex.RLock()
+	entry, ok := r.cache[ip]
+	r.cacheRWMutex.RUnlock()
+	if !ok || time.Now().After(entry.expires) {
+		r.enqueue(ip)
+	}
+	return entry.name
+}
+
+func (r *ReverseDNS) inSubnets(ip net.IP) bool {
+	for _, subnet := range r.subnets {
+		if subnet.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *ReverseDNS) enqueue(ip string) {
+	r.pendingMutex.Lock()
+	defer r.pendingMutex.Unlock()
+	if r.pending[ip] {
+		return
+	}
+	select {
+	case r.queue <- ip:
+		r.pending[ip] = true
+	default:
+		// The queue is full, the IP will be queued again on a later scrape
+	}
+}
+
+func (r *ReverseDNS) resolve(ip string) {
+	ctx, cancel := context.WithTimeout(context.Background(), r.timeout)
+	defer cancel()
+	entry := rdnsCacheEntry{expires: time.Now().Add(r.negativeTTL)}
+	names, err := r.resolver.LookupAddr(ctx, ip)
+	if err == nil && len(names) > 0 {
+		entry = rdnsCacheEntry{name: strings.TrimSuffix(names[0], "."), expires: time.Now().Add(r.cacheTTL)}
+	}
+
+	r.cacheRWMutex.Lock()
+	r.cache[ip] = entry
+	// Entries for hosts that have gone away are dropped once they have been expired for a full cache TTL
+	if time.Since(r.lastCleanup) > r.cacheTTL {
+		for cachedIP, cachedEntry := range r.cache {
+			if time.Since(cachedEntry.expires) > r.cacheTTL {
+				delete(r.cache, cachedIP)
+			}
+		}
+		r.lastCleanup = time.Now()
+	}
+	r.cacheRWMutex.Unlock()
+
+	r.pendingMutex.Lock()
+	delete(r.pending, ip)
+	r.pendingMutex.Unlock()
+}
@@ -0,0 +1,323 @@
+package enrichment
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// stubResolver answers PTR lookups from names and records when each lookup was made
+type stubResolver struct {
+	names map[string]string
+	mutex sync.Mutex
+	calls []time.Time
+}
+
+func (s *stubResolver) LookupAddr(_ context.Context, addr string) ([]string, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.calls = append(s.calls, time.Now())
+	if name, ok := s.names[addr]; ok {
+		return []string{name + "."}, nil
+	}
+	return nil, fmt.Errorf("no PTR record for %s", addr)
+}
+
+func (s *stubResolver) callTimes() []time.Time {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return append([]time.Time(nil), s.calls...)
+}
+
+func newTestReverseDNS(t *testing.T, lookupsPerSecond int) (*ReverseDNS, *stubResolver) {
+	t.Helper()
+	rdns, err := NewReverseDNS([]string{"10.0.0.0/8"}, "", time.Hour, time.Minute, time.Second, lookupsPerSecond)
+	if err != nil {
+		t.Fatalf("NewReverseDNS() returned error: %v", err)
+	}
+	resolver := &stubResolver{names: map[string]string{"10.0.0.1": "host1.example.com"}}
+	rdns.resolver = resolver
+	return rdns, resolver
+}
+
+func TestReverseDNSCacheTTL(t *testing.T) {
+	tests := []struct {
+		name        string
+		ip          string
+		wantName    string
+		wantExpires time.Duration
+	}{
+		{name: "found names are cached for the cache TTL", ip: "10.0.0.1", wantName: "host1.example.com", wantExpires: time.Hour},
+		{name: "missing names are cached for the negative TTL", ip: "10.0.0.2", wantName: "", wantExpires: time.Minute},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rdns, _ := newTestReverseDNS(t, 1)
+			if name := rdns.Lookup(tt.ip); name != "" {
+				t.Errorf("Lookup(%s) before resolving = %q, want an empty name", tt.ip, name)
+			}
+			if len(rdns.queue) != 1 {
+				t.Fatalf("Lookup(%s) queued %d lookups, want 1", tt.ip, len(rdns.queue))
+			}
+			rdns.resolve(<-rdns.queue)
+
+			entry := rdns.cache[tt.ip]
+			if ttl := time.Until(entry.expires); ttl > tt.wantExpires || ttl < tt.wantExpires-time.Minute/2 {
+				t.Errorf("cache entry for %s expires in %v, want %v", tt.ip, ttl, tt.wantExpires)
+			}
+			if name := rdns.Lookup(tt.ip); name != tt.wantName {
+				t.Errorf("Lookup(%s) = %q, want %q", tt.ip, name, tt.wantName)
+			}
+			if len(rdns.queue) != 0 {
+				t.Errorf("Lookup(%s) of a fresh cache entry queued a lookup", tt.ip)
+			}
+
+			entry.expires = time.Now().Add(-time.Second)
+			rdns.cache[tt.ip] = entry
+			if name := rdns.Lookup(tt.ip); name != tt.wantName {
+				t.Errorf("Lookup(%s) of an expired cache entry = %q, want the stale name %q", tt.ip, name, tt.wantName)
+			}
+			if len(rdns.queue) != 1 {
+				t.Errorf("Lookup(%s) of an expired cache entry queued %d lookups, want 1", tt.ip, len(rdns.queue))
+			}
+		})
+	}
+}
+
+func TestReverseDNSLookupOutsideSubnets(t *testing.T) {
+	rdns, _ := newTestReverseDNS(t, 1)
+	for _, ip := range []string{"192.168.1.1", "2001:db8::1", "not an ip"} {
+		if name := rdns.Lookup(ip); name != "" {
+			t.Errorf("Lookup(%s) = %q, want an empty name", ip, name)
+		}
+	}
+	if len(rdns.queue) != 0 {
+		t.Errorf("Lookup() of IPs outside of the subnets queued %d lookups, want 0", len(rdns.queue))
+	}
+}
+
+func TestReverseDNSRateLimit(t *testing.T) {
+	const lookupsPerSecond = 20
+	const lookups = 4
+	rdns, resolver := newTestReverseDNS(t, lookupsPerSecond)
+	for i := 1; i <= lookups; i++ {
+		rdns.Lookup(fmt.Sprintf("10.0.0.%d", i))
+	}
+	stopChan := make(chan struct{})
+	done := make(chan struct{})
+	start := time.Now()
+	go func() {
+		rdns.Run(stopChan)
+		close(done)
+	}()
+	defer func() {
+		close(stopChan)
+		<-done
+	}()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for len(resolver.callTimes()) < lookups && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	calls := resolver.callTimes()
+	if len(calls) != lookups {
+		t.Fatalf("resolver was called %d times, want %d", len(calls), lookups)
+	}
+	// Every lookup waits for its own tick of the rate limiter, so the nth lookup can't happen before n periods
+	period := time.Second / lookupsPerSecond
+	for i, call := range calls {
+		if earliest := time.Duration(i+1) * period; call.Sub(start) < earliest-5*time.Millisecond {
+			t.Errorf("lookup %d happened after %v, want no earlier than %v", i+1, call.Sub(start), earliest)
+		}
+	}
+}
+
+func TestReverseDNSQueueOverflow(t *testing.T) {
+	rdns, _ := newTestReverseDNS(t, 1)
+	ip := func(i int) string {
+		return fmt.Sprintf("10.%d.%d.%d", i>>16&0xff, i>>8&0xff, i&0xff)
+	}
+	const overflow = 10
+	for i := 0; i < rdnsQueueSize+overflow; i++ {
+		rdns.Lookup(ip(i))
+	}
+	// Looking up an IP that is already queued doesn't queue it a second time
+	rdns.Lookup(ip(0))
+	if len(rdns.queue) != rdnsQueueSize {
+		t.Errorf("queue holds %d lookups, want %d", len(rdns.queue), rdnsQueueSize)
+	}
+	if len(rdns.pending) != rdnsQueueSize {
+		t.Errorf("%d lookups are pending, want %d", len(rdns.pending), rdnsQueueSize)
+	}
+	overflowedIP := ip(rdnsQueueSize)
+	if rdns.pending[overflowedIP] {
+		t.Fatalf("%s is pending although the queue was full", overflowedIP)
+	}
+
+	// An IP that didn't fit is queued again by a later lookup once there is room
+	rdns.resolve(<-rdns.queue)
+	rdns.Lookup(overflowedIP)
+	if !rdns.pending[overflowedIP] {
+		t.Errorf("%s wasn't queued after the queue had room again", overflowedIP)
+	}
+}
+
+// stubDNSServer answers PTR queries over UDP from names, which is keyed by the reverse name of each IP (e.g.
+// 1.0.0.10.in-addr.arpa), and answers every other query with NXDOMAIN
+type stubDNSServer struct {
+	conn    net.PacketConn
+	mutex   sync.Mutex
+	names   map[string]string
+	queries map[string]int
+}
+
+func startStubDNSServer(t *testing.T, names map[string]string) *stubDNSServer {
+	t.Helper()
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("was not able to listen for DNS queries: %v", err)
+	}
+	server := &stubDNSServer{conn: conn, names: names, queries: make(map[string]int)}
+	go server.serve()
+	t.Cleanup(func() { _ = conn.Close() })
+	return server
+}
+
+func (s *stubDNSServer) serve() {
+	buf := make([]byte, 512)
+	for {
+		n, addr, err := s.conn.ReadFrom(buf)
+		if err != nil {
+			return
+		}
+		if response := s.answer(buf[:n]); response != nil {
+			_, _ = s.conn.WriteTo(response, addr)
+		}
+	}
+}
+
+// answer builds the response to a single query, the question is parsed just far enough to find the name and type
+func (s *stubDNSServer) answer(query []byte) []byte {
+	const headerLen, ptrType = 12, 12
+	var labels []string
+	offset := headerLen
+	for offset < len(query) && query[offset] != 0 {
+		end := offset + 1 + int(query[offset])
+		if end > len(query) {
+			return nil
+		}
+		labels = append(labels, string(query[offset+1:end]))
+		offset = end
+	}
+	questionEnd := offset + 5
+	if questionEnd > len(query) {
+		return nil
+	}
+	name := strings.ToLower(strings.Join(labels, "."))
+	qType := binary.BigEndian.Uint16(query[offset+1:])
+
+	s.mutex.Lock()
+	s.queries[name]++
+	ptrName, ok := s.names[name]
+	s.mutex.Unlock()
+
+	response := append([]byte(nil), query[:questionEnd]...)
+	// Flags are a recursion available response to a recursion desired query, with a return code of NXDOMAIN unless an
+	// answer follows, and there are no authority or additional records
+	binary.BigEndian.PutUint16(response[2:], 0x8183)
+	binary.BigEndian.PutUint16(response[6:], 0)
+	binary.BigEndian.PutUint16(response[8:], 0)
+	binary.BigEndian.PutUint16(response[10:], 0)
+	if !ok || qType != ptrType {
+		return response
+	}
+	binary.BigEndian.PutUint16(response[2:], 0x8180)
+	binary.BigEndian.PutUint16(response[6:], 1)
+	var rdata []byte
+	for _, label := range strings.Split(ptrName, ".") {
+		rdata = append(append(rdata, byte(len(label))), label...)
+	}
+	rdata = append(rdata, 0)
+	// The answer refers back to the name of the question, which always starts right after the header
+	response = binary.BigEndian.AppendUint16(response, 0xc000|headerLen)
+	response = binary.BigEndian.AppendUint16(response, ptrType)
+	response = binary.BigEndian.AppendUint16(response, 1)
+	response = binary.BigEndian.AppendUint32(response, 3600)
+	response = binary.BigEndian.AppendUint16(response, uint16(len(rdata)))
+	return append(response, rdata...)
+}
+
+func (s *stubDNSServer) setName(reverseName string, name string) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.names[reverseName] = name
+}
+
+func (s *stubDNSServer) queryCount(reverseName string) int {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.queries[reverseName]
+}
+
+func TestReverseDNSServer(t *testing.T) {
+	const cacheTTL, negativeTTL = 400 * time.Millisecond, 100 * time.Millisecond
+	const knownName, unknownName = "1.0.0.10.in-addr.arpa", "2.0.0.10.in-addr.arpa"
+	server := startStubDNSServer(t, map[string]string{knownName: "host1.example.com"})
+	rdns, err := NewReverseDNS([]string{"10.0.0.0/8"}, server.conn.LocalAddr().String(), cacheTTL, negativeTTL,
+		2*time.Second, 10)
+	if err != nil {
+		t.Fatalf("NewReverseDNS() returned error: %v", err)
+	}
+	// lookup resolves the IP if Lookup queued it, and returns the name that Lookup returns afterwards
+	lookup := func(ip string) string {
+		if name := rdns.Lookup(ip); len(rdns.queue) < 1 {
+			return name
+		}
+		rdns.resolve(<-rdns.queue)
+		return rdns.Lookup(ip)
+	}
+
+	if name := lookup("10.0.0.1"); name != "host1.example.com" {
+		t.Errorf("Lookup(10.0.0.1) = %q, want %q", name, "host1.example.com")
+	}
+	if name := lookup("10.0.0.2"); name != "" {
+		t.Errorf("Lookup(10.0.0.2) = %q, want an empty name", name)
+	}
+	if count := server.queryCount(unknownName); count != 1 {
+		t.Fatalf("server got %d queries for %s, want 1", count, unknownName)
+	}
+
+	// Missing names are looked up again once the negative TTL has passed
+	time.Sleep(negativeTTL + 50*time.Millisecond)
+	if name := lookup("10.0.0.2"); name != "" {
+		t.Errorf("Lookup(10.0.0.2) = %q, want an empty name", name)
+	}
+	if count := server.queryCount(unknownName); count != 2 {
+		t.Errorf("server got %d queries for %s after the negative TTL, want 2", count, unknownName)
+	}
+	if count := server.queryCount(knownName); count != 1 {
+		t.Errorf("server got %d queries for %s within the cache TTL, want 1", count, knownName)
+	}
+
+	// Found names are looked up again once the cache TTL has passed, and the stale name is returned meanwhile
+	server.setName(knownName, "host1.example.org")
+	time.Sleep(cacheTTL)
+	if name := rdns.Lookup("10.0.0.1"); name != "host1.example.com" {
+		t.Errorf("Lookup(10.0.0.1) of an expired name = %q, want the stale name %q", name, "host1.example.com")
+	}
+	if len(rdns.queue) != 1 {
+		t.Fatalf("Lookup(10.0.0.1) of an expired name queued %d lookups, want 1", len(rdns.queue))
+	}
+	rdns.resolve(<-rdns.queue)
+	if name := rdns.Lookup("10.0.0.1"); name != "host1.example.org" {
+		t.Errorf("Lookup(10.0.0.1) after the cache TTL = %q, want %q", name, "host1.example.org")
+	}
+	if count := server.queryCount(knownName); count != 2 {
+		t.Errorf("server got %d queries for %s after the cache TTL, want 2", count, knownName)
+	}
+}
@@ -0,0 +1,208 @@
+//go:build ignore
+
+// gen_city_mmdb writes testdata/city.mmdb, the MaxMind DB fixture used by mmdb_test.go. It is run from the enrichment
+// package directory with:
+//
+//	go run testdata/gen_city_mmdb.go
+//
+// The database has an IPv6 search tree with a record size of 28 bits, and contains:
+//
+//	8.8.8.0/24     country (pointer to the shared {iso_code: US} map), city.names.en "Mountain View",
+//	               subdivisions [{iso_code: CA}, {iso_code: SC}], location.latitude 37.386 (double),
+//	               is_anycast true (bool), autonomous_system_number 15169 (uint32) and
+//	               autonomous_system_organization "GOOGLE"
+//	2001:db8::/32  country (pointer to the shared {iso_code: US} map), city.names.en "Documentation"
+//
+// Its metadata has the GeoLite2-City database type.
+package main
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+	"net"
+	"os"
+)
+
+const (
+	outputPath = "testdata/city.mmdb"
+	recordSize = 28
+	// ipv4Offset is where IPv4 addresses start in an IPv6 search tree, as they are stored as ::a.b.c.d
+	ipv4Offset       = 96
+	separatorSize    = 16
+	metadataMarker   = "\xAB\xCD\xEFMaxMind.com"
+	maxPointerOffset = 2048
+)
+
+// pointer is an offset into the data section that is encoded as an MMDB pointer
+type pointer int
+
+// entry is a key in an orderedMap, maps are ordered so that the output is the same every time it is generated
+type entry struct {
+	key   string
+	value any
+}
+
+type orderedMap []entry
+
+// node is a search tree node, a child is either another node or an offset into the data section
+type node struct {
+	children [2]*child
+}
+
+type child struct {
+	node       int
+	dataOffset int
+	isData     bool
+}
+
+func main() {
+	var data []byte
+	sharedCountry := pointer(len(data))
+	data = append(data, encode(orderedMap{{"iso_code", "US"}})...)
+
+	records := []struct {
+		cidr   string
+		record orderedMap
+	}{
+		{
+			cidr: "8.8.8.0/24",
+			record: orderedMap{
+				{"country", sharedCountry},
+				{"city", orderedMap{{"names", orderedMap{{"en", "Mountain View"}}}}},
+				{"subdivisions", []any{orderedMap{{"iso_code", "CA"}}, orderedMap{{"iso_code", "SC"}}}},
+				{"location", orderedMap{{"latitude", 37.386}}},
+				{"is_anycast", true},
+				{"autonomous_system_number", uint32(15169)},
+				{"autonomous_system_organization", "GOOGLE"},
+			},
+		},
+		{
+			cidr: "2001:db8::/32",
+			record: orderedMap{
+				{"country", sharedCountry},
+				{"city", orderedMap{{"names", orderedMap{{"en", "Documentation"}}}}},
+			},
+		},
+	}
+
+	nodes := []*node{{}}
+	for _, r := range records {
+		_, ipNet, err := net.ParseCIDR(r.cidr)
+		if err != nil {
+			panic(err)
+		}
+		bits, _ := ipNet.Mask.Size()
+		ip := make(net.IP, net.IPv6len)
+		if ipv4 := ipNet.IP.To4(); ipv4 != nil {
+			bits += ipv4Offset
+			copy(ip[ipv4Offset/8:], ipv4)
+		} else {
+			copy(ip, ipNet.IP)
+		}
+
+		dataOffset := len(data)
+		data = append(data, encode(r.record)...)
+
+		current := 0
+		for i := range bits {
+			bit := (ip[i/8] >> (7 - i%8)) & 1
+			if i == bits-1 {
+				nodes[current].children[bit] = &child{dataOffset: dataOffset, isData: true}
+				break
+			}
+			if nodes[current].children[bit] == nil {
+				nodes = append(nodes, &node{})
+				nodes[current].children[bit] = &child{node: len(nodes) - 1}
+			}
+			current = nodes[current].children[bit].node
+		}
+	}
+
+	nodeCount := len(nodes)
+	// recordValue is what a child is stored as in the search tree: a node number, the node count for an empty
+	// record, or a data section offset past the node count and data section separator
+	recordValue := func(c *child) int {
+		switch {
+		case c == nil:
+			return nodeCount
+		case c.isData:
+			return nodeCount + separatorSize + c.dataOffset
+		default:
+			return c.node
+		}
+	}
+	var database []byte
+	for _, n := range nodes {
+		left, right := recordValue(n.children[0]), recordValue(n.children[1])
+		database = append(database, byte(left>>16), byte(left>>8), byte(left), byte((left>>24)<<4|right>>24),
+			byte(right>>16), byte(right>>8), byte(right))
+	}
+	database = append(database, make([]byte, separatorSize)...)
+	database = append(database, data...)
+	database = append(database, metadataMarker...)
+	database = append(database, encode(orderedMap{
+		{"node_count", uint32(nodeCount)},
+		{"record_size", uint32(recordSize)},
+		{"ip_version", uint32(6)},
+		{"database_type", "GeoLite2-City"},
+	})...)
+
+	if err := os.WriteFile(outputPath, database, 0o644); err != nil {
+		panic(err)
+	}
+	fmt.Printf("wrote %d bytes with %d search tree nodes to %s\n", len(database), nodeCount, outputPath)
+}
+
+// encode returns the MMDB data section encoding of value, only the types used by the fixture are supported
+func encode(value any) []byte {
+	switch v := value.(type) {
+	case pointer:
+		if v >= maxPointerOffset {
+			panic(fmt.Sprintf("pointer offset %d doesn't fit in a one byte pointer", v))
+		}
+		return []byte{1<<5 | byte(v>>8)&0x7, byte(v)}
+	case bool:
+		var b byte
+		if v {
+			b = 1
+		}
+		// Extended types have a type of 0 in the control byte followed by their type minus 7
+		return []byte{b, 14 - 7}
+	case string:
+		switch {
+		case len(v) < 29:
+			return append([]byte{2<<5 | byte(len(v))}, v...)
+		case len(v) < 29+256:
+			return append([]byte{2<<5 | 29, byte(len(v) - 29)}, v...)
+		default:
+			panic(fmt.Sprintf("string %q is too long", v))
+		}
+	case float64:
+		out := []byte{3<<5 | 8}
+		return binary.BigEndian.AppendUint64(out, math.Float64bits(v))
+	case uint32:
+		var raw []byte
+		for shift := 24; shift >= 0; shift -= 8 {
+			if b := byte(v >> shift); b != 0 || len(raw) > 0 {
+				raw = append(raw, b)
+			}
+		}
+		return append([]byte{6<<5 | byte(len(raw))}, raw...)
+	case []any:
+		out := []byte{byte(len(v)), 11 - 7}
+		for _, item := range v {
+			out = append(out, encode(item)...)
+		}
+		return out
+	case orderedMap:
+		out := []byte{7<<5 | byte(len(v))}
+		for _, e := range v {
+			out = append(out, encode(e.key)...)
+			out = append(out, encode(e.value)...)
+		}
+		return out
+	default:
+		panic(fmt.Sprintf("unsupported type %T", value))
+	}
+}
@@ -0,0 +1,59 @@
+package prometheus
+
+import (
+	"strconv"
+
+	"github.com/aauren/ntopng-exporter/internal/config"
+	"github.com/aauren/ntopng-exporter/internal/ntopng"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var asStatsLabels = []string{"asn", "as_name", "ifname"}
+
+type asStatsCollector struct {
+	ntopNGController *ntopng.Controller
+	config           *config.Config
+	metrics          *metricSet
+	bytesRcvd        *prometheus.Desc
+	bytesSent        *prometheus.Desc
+	numHosts         *prometheus.Desc
+	score            *prometheus.Desc
+	throughputBPS    *prometheus.Desc
+}
+
+func NewNtopNGASStatsCollector(ntopController *ntopng.Controller, config *config.Config) *asStatsCollector {
+	metrics := newMetricSet(ntopController, config)
+	return &asStatsCollector{
+		ntopNGController: ntopController,
+		config:           config,
+		metrics:          metrics,
+		bytesRcvd: metrics.newDesc("as", "bytes_rcvd",
+			"number of bytes received from autonomous system as computed by ntopng", asStatsLabels),
+		bytesSent: metrics.newDesc("as", "bytes_sent",
+			"number of bytes sent to autonomous system as computed by ntopng", asStatsLabels),
+		numHosts: metrics.newDesc("as", "num_hosts",
+			"number of active hosts in autonomous system", asStatsLabels),
+		score: metrics.newDesc("as", "score",
+			"current ntopng score of autonomous system", asStatsLabels),
+		throughputBPS: metrics.newDesc("as", "current_throughput_bps",
+			"current throughput of autonomous system in bytes per second", asStatsLabels),
+	}
+}
+
+func (c *asStatsCollector) Describe(ch chan<- *prometheus.Desc) {
+	c.metrics.describe(ch)
+}
+
+func (c *asStatsCollector) Collect(ch chan<- prometheus.Metric) {
+	m := c.metrics.sink(ch)
+	c.ntopNGController.ListRWMutex.RLock()
+	defer c.ntopNGController.ListRWMutex.RUnlock()
+	for _, as := range c.ntopNGController.ASList {
+		var asLabelValues = []string{strconv.Itoa(as.ASN), as.ASName, as.IfName}
+		m.emit(c.bytesRcvd, prometheus.CounterValue, as.BytesReceived, asLabelValues...)
+		m.emit(c.bytesSent, prometheus.CounterValue, as.BytesSent, asLabelValues...)
+		m.emit(c.numHosts, prometheus.GaugeValue, as.NumHosts, asLabelValues...)
+		m.emit(c.score, prometheus.GaugeValue, as.Score, asLabelValues...)
+		m.emit(c.throughputBPS, prometheus.GaugeValue, as.ThroughputBPS, asLabelValues...)
+	}
+}
@@ -0,0 +1,61 @@
+package prometheus
+
+import (
+	"github.com/aauren/ntopng-exporter/internal/config"
+	"github.com/aauren/ntopng-exporter/internal/ntopng"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var countryStatsLabels = []string{"country", "ifname"}
+
+type countryStatsCollector struct {
+	ntopNGController *ntopng.Controller
+	config           *config.Config
+	metrics          *metricSet
+	egressBytes      *prometheus.Desc
+	ingressBytes     *prometheus.Desc
+	innerBytes       *prometheus.Desc
+	numHosts         *prometheus.Desc
+	score            *prometheus.Desc
+	throughputBPS    *prometheus.Desc
+}
+
+func NewNtopNGCountryStatsCollector(ntopController *ntopng.Controller, config *config.Config) *countryStatsCollector {
+	metrics := newMetricSet(ntopController, config)
+	return &countryStatsCollector{
+		ntopNGController: ntopController,
+		config:           config,
+		metrics:          metrics,
+		egressBytes: metrics.newDesc("country", "egress_bytes",
+			"number of bytes sent from local hosts to country as computed by ntopng", countryStatsLabels),
+		ingressBytes: metrics.newDesc("country", "ingress_bytes",
+			"number of bytes received by local hosts from country as computed by ntopng", countryStatsLabels),
+		innerBytes: metrics.newDesc("country", "inner_bytes",
+			"number of bytes exchanged between hosts within country as computed by ntopng", countryStatsLabels),
+		numHosts: metrics.newDesc("country", "num_hosts",
+			"number of active hosts in country", countryStatsLabels),
+		score: metrics.newDesc("country", "score",
+			"current ntopng score of country", countryStatsLabels),
+		throughputBPS: metrics.newDesc("country", "current_throughput_bps",
+			"current throughput of country in bytes per second", countryStatsLabels),
+	}
+}
+
+func (c *countryStatsCollector) Describe(ch chan<- *prometheus.Desc) {
+	c.metrics.describe(ch)
+}
+
+func (c *countryStatsCollector) Collect(ch chan<- prometheus.Metric) {
+	m := c.metrics.sink(ch)
+	c.ntopNGController.ListRWMutex.RLock()
+	defer c.ntopNGController.ListRWMutex.RUnlock()
+	for _, country := range c.ntopNGController.CountryList {
+		var countryLabelValues = []string{country.Country, country.IfName}
+		m.emit(c.egressBytes, prometheus.CounterValue, country.EgressBytes, countryLabelValues...)
+		m.emit(c.ingressBytes, prometheus.CounterValue, country.IngressBytes, countryLabelValues...)
+		m.emit(c.innerBytes, prometheus.CounterValue, country.InnerBytes, countryLabelValues...)
+		m.emit(c.numHosts, prometheus.GaugeValue, country.NumHosts, countryLabelValues...)
+		m.emit(c.score, prometheus.GaugeValue, country.Score, countryLabelValues...)
+		m.emit(c.throughputBPS, prometheus.GaugeValue, country.ThroughputBPS, countryLabelValues...)
+	}
+}
@@ -0,0 +1,65 @@
+package prometheus
+
+import (
+	"github.com/aauren/ntopng-exporter/internal/config"
+	"github.com/aauren/ntopng-exporter/internal/ntopng"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// customMetric is the descriptor built for one of the configured custom host fields or custom endpoint metrics
+type customMetric struct {
+	desc      *prometheus.Desc
+	valueType prometheus.ValueType
+}
+
+type customCollector struct {
+	ntopNGController *ntopng.Controller
+	config           *config.Config
+	metrics          *metricSet
+	// customMetrics is indexed first by custom endpoint and then by metric, in the same order as the config
+	customMetrics [][]customMetric
+}
+
+func NewNtopNGCustomCollector(ntopController *ntopng.Controller, config *config.Config) *customCollector {
+	metrics := newMetricSet(ntopController, config)
+	customMetrics := make([][]customMetric, len(config.Metric.CustomEndpoints))
+	for i := range config.Metric.CustomEndpoints {
+		endpoint := &config.Metric.CustomEndpoints[i]
+		for j := range endpoint.Metrics {
+			metric := &endpoint.Metrics[j]
+			labels := metric.LabelNames()
+			if endpoint.PerInterface() {
+				labels = append(labels, "ifname")
+			}
+			valueType := prometheus.GaugeValue
+			if metric.IsCounter() {
+				valueType = prometheus.CounterValue
+			}
+			customMetrics[i] = append(customMetrics[i], customMetric{
+				desc: metrics.newDesc(endpoint.Name, metric.Name,
+					metric.Help, labels),
+				valueType: valueType,
+			})
+		}
+	}
+	return &customCollector{
+		ntopNGController: ntopController,
+		config:           config,
+		metrics:          metrics,
+		customMetrics:    customMetrics,
+	}
+}
+
+func (c *customCollector) Describe(ch chan<- *prometheus.Desc) {
+	c.metrics.describe(ch)
+}
+
+func (c *customCollector) Collect(ch chan<- prometheus.Metric) {
+	m := c.metrics.sink(ch)
+	c.ntopNGController.ListRWMutex.RLock()
+	defer c.ntopNGController.ListRWMutex.RUnlock()
+	for _, sample := range c.ntopNGController.CustomSamples {
+		metric := c.customMetrics[sample.Endpoint][sample.Metric]
+		m.emit(metric.desc, metric.valueType, sample.Value, sample.LabelValues...)
+	}
+}
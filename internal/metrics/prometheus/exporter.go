@@ -0,0 +1,48 @@
+package prometheus
+
+import (
+	"github.com/aauren/ntopng-exporter/internal/config"
+	"github.com/aauren/ntopng-exporter/internal/ntopng"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var exporterMetricLabels = []string{"metric"}
+
+// exporterCollector exports metrics about the exporter itself rather than about anything that ntopng has seen
+type exporterCollector struct {
+	ntopNGController *ntopng.Controller
+	config           *config.Config
+	metrics          *metricSet
+	counterResets    *prometheus.Desc
+	invalidMetrics   *prometheus.Desc
+}
+
+func NewNtopNGExporterCollector(ntopController *ntopng.Controller, config *config.Config) *exporterCollector {
+	metrics := newMetricSet(ntopController, config)
+	return &exporterCollector{
+		ntopNGController: ntopController,
+		config:           config,
+		metrics:          metrics,
+		counterResets: metrics.newDesc("", "counter_resets_total",
+			"number of times that ntopng reset a counter that the exporter keeps monotonic", exporterMetricLabels),
+		invalidMetrics: metrics.newDesc("exporter", "invalid_metrics_total",
+			"number of metrics that couldn't be built from the data scraped from ntopng", exporterMetricLabels),
+	}
+}
+
+func (c *exporterCollector) Describe(ch chan<- *prometheus.Desc) {
+	c.metrics.describe(ch)
+}
+
+func (c *exporterCollector) Collect(ch chan<- prometheus.Metric) {
+	m := c.metrics.sink(ch)
+	for metric, count := range c.ntopNGController.InvalidMetrics.Counts() {
+		m.emit(c.invalidMetrics, prometheus.CounterValue, count, metric)
+	}
+	if c.ntopNGController.Counters == nil {
+		return
+	}
+	for metric, count := range c.ntopNGController.Counters.Resets() {
+		m.emit(c.counterResets, prometheus.CounterValue, count, metric)
+	}
+}
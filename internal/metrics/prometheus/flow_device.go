@@ -0,0 +1,68 @@
+package prometheus
+
+import (
+	"strconv"
+
+	"github.com/aauren/ntopng-exporter/internal/config"
+	"github.com/aauren/ntopng-exporter/internal/ntopng"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	flowDeviceLabels     = []string{"exporter_ip", "probe_ip", "ifname"}
+	flowDeviceInfoLabels = deepAppend(flowDeviceLabels, "probe_uuid", "probe_ifindex")
+)
+
+type flowDeviceCollector struct {
+	ntopNGController    *ntopng.Controller
+	config              *config.Config
+	metrics             *metricSet
+	drops               *prometheus.Desc
+	flows               *prometheus.Desc
+	info                *prometheus.Desc
+	lastSeen            *prometheus.Desc
+	zmqMessagesDropped  *prometheus.Desc
+	zmqMessagesReceived *prometheus.Desc
+}
+
+func NewNtopNGFlowDeviceCollector(ntopController *ntopng.Controller, config *config.Config) *flowDeviceCollector {
+	metrics := newMetricSet(ntopController, config)
+	return &flowDeviceCollector{
+		ntopNGController: ntopController,
+		config:           config,
+		metrics:          metrics,
+		drops: metrics.newDesc("flowdevice", "drops",
+			"number of flows from flow exporter that were dropped", flowDeviceLabels),
+		flows: metrics.newDesc("flowdevice", "flows",
+			"number of flows received from flow exporter", flowDeviceLabels),
+		info: metrics.newDesc("flowdevice", "info",
+			"metadata about the probe that flow exporter is sending through, always has a value of 1",
+			flowDeviceInfoLabels),
+		lastSeen: metrics.newDesc("flowdevice", "last_seen_timestamp_seconds",
+			"unix timestamp of when ntopng last received flows from flow exporter", flowDeviceLabels),
+		zmqMessagesDropped: metrics.newDesc("flowdevice", "zmq_messages_dropped",
+			"number of ZMQ messages from the probe of flow exporter that were dropped", flowDeviceLabels),
+		zmqMessagesReceived: metrics.newDesc("flowdevice", "zmq_messages_rcvd",
+			"number of ZMQ messages received from the probe of flow exporter", flowDeviceLabels),
+	}
+}
+
+func (c *flowDeviceCollector) Describe(ch chan<- *prometheus.Desc) {
+	c.metrics.describe(ch)
+}
+
+func (c *flowDeviceCollector) Collect(ch chan<- prometheus.Metric) {
+	m := c.metrics.sink(ch)
+	c.ntopNGController.ListRWMutex.RLock()
+	defer c.ntopNGController.ListRWMutex.RUnlock()
+	for _, device := range c.ntopNGController.FlowDeviceList {
+		var deviceLabelValues = []string{device.ExporterIP, device.ProbeIP, device.IfName}
+		m.emit(c.info, prometheus.GaugeValue, 1,
+			deepAppend(deviceLabelValues, device.ProbeUUID, strconv.Itoa(device.ProbeIfIndex))...)
+		m.emit(c.drops, prometheus.CounterValue, device.Drops, deviceLabelValues...)
+		m.emit(c.flows, prometheus.CounterValue, device.Flows, deviceLabelValues...)
+		m.emit(c.lastSeen, prometheus.GaugeValue, device.LastSeen, deviceLabelValues...)
+		m.emit(c.zmqMessagesDropped, prometheus.CounterValue, device.ZMQMessagesDropped, deviceLabelValues...)
+		m.emit(c.zmqMessagesReceived, prometheus.CounterValue, device.ZMQMessagesReceived, deviceLabelValues...)
+	}
+}
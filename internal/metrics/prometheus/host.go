@@ -9,174 +9,302 @@ import (
 )
 
 var (
-	hostLabels       = []string{"ip", "ifname", "mac", "name", "vlan"}
-	basicDNSLabels   = deepAppend(hostLabels, "direction")
-	DNSRepliesLabels = deepAppend(basicDNSLabels, "status")
-	DNSQueriesLabels = deepAppend(basicDNSLabels, "record_type")
+	hostLabels        = []string{"ip", "ifname", "mac", "name", "vlan"}
+	minimalHostLabels = []string{"ip", "ifname", "vlan"}
+	inventoryLabels   = []string{"owner", "device_type", "site"}
+	hostInfoLabels    = deepAppend(hostLabels, "vendor", "os")
+	// inventoryInfoLabels are only added to the info metric when an inventory is configured, so that they are free to be
+	// used as constant labels otherwise
+	inventoryInfoLabels = deepAppend([]string{"hostname"}, inventoryLabels...)
+	geoInfoLabels       = []string{"country", "city", "asn", "as_org"}
 )
 
 type hostCollector struct {
 	ntopNGController  *ntopng.Controller
 	config            *config.Config
+	metrics           *metricSet
+	hostInfo          *prometheus.Desc
 	activeClientFlows *prometheus.Desc
 	activeServerFlows *prometheus.Desc
 	bytesRcvd         *prometheus.Desc
+	blacklisted       *prometheus.Desc
 	bytesSent         *prometheus.Desc
+	customFields      []customMetric
 	DNSQueryTypes     *prometheus.Desc
+	duration          *prometheus.Desc
+	hostsFiltered     *prometheus.Desc
+	isLocal           *prometheus.Desc
+	lastScraped       *prometheus.Desc
+	misbehavingFlows  *prometheus.Desc
 	numAlerts         *prometheus.Desc
+	osInfo            *prometheus.Desc
 	packetsRcvd       *prometheus.Desc
 	packetsSent       *prometheus.Desc
+	score             *prometheus.Desc
+	seenFirst         *prometheus.Desc
+	seenLast          *prometheus.Desc
+	tcpPacketStats    *prometheus.Desc
+	throughputBPS     *prometheus.Desc
+	throughputPPS     *prometheus.Desc
 	totalAlerts       *prometheus.Desc
 	totalClientFlows  *prometheus.Desc
 	totalDNSQueries   *prometheus.Desc
 	totalDNSReplies   *prometheus.Desc
 	totalServerFlows  *prometheus.Desc
+	unreachableFlows  *prometheus.Desc
 }
 
 func NewNtopNGHostCollector(ntopController *ntopng.Controller, config *config.Config) *hostCollector {
+	metrics := newMetricSet(ntopController, config)
+	labels := hostLabels
+	if config.Metric.MinimalHostLabels() {
+		labels = minimalHostLabels
+	} else {
+		if config.Enrichment.Inventory.OverrideLabels {
+			labels = deepAppend(hostLabels, inventoryLabels...)
+		}
+		if config.Metric.HostPoolLabel {
+			labels = deepAppend(labels, "pool")
+		}
+	}
+	basicDNSLabels := deepAppend(labels, "direction")
+	DNSRepliesLabels := deepAppend(basicDNSLabels, "status")
+	DNSQueriesLabels := deepAppend(basicDNSLabels, "record_type")
+	roleLabels := deepAppend(labels, "role")
+	tcpPacketLabels := deepAppend(labels, "direction", "type")
+	infoLabels := hostInfoLabels
+	if config.Enrichment.Inventory.Enabled() {
+		infoLabels = deepAppend(infoLabels, inventoryInfoLabels...)
+	}
+	if config.Enrichment.GeoIP.InfoLabels {
+		infoLabels = deepAppend(infoLabels, geoInfoLabels...)
+	}
+	if config.Metric.HostPoolLabel {
+		infoLabels = deepAppend(infoLabels, "pool")
+	}
+	var customFields []customMetric
+	for _, customField := range config.Metric.CustomHostFields {
+		valueType := prometheus.GaugeValue
+		if customField.IsCounter() {
+			valueType = prometheus.CounterValue
+		}
+		customFields = append(customFields, customMetric{
+			desc: metrics.newDesc("host", customField.Name,
+				customField.Help, deepAppend(labels, customField.KeyLabels...)),
+			valueType: valueType,
+		})
+	}
 	return &hostCollector{
 		ntopNGController: ntopController,
 		config:           config,
-		activeClientFlows: prometheus.NewDesc(
-			prometheus.BuildFQName("ntopng", "host", "active_client_flows"),
-			"current number of active client flows for host",
-			hostLabels,
-			nil),
-		activeServerFlows: prometheus.NewDesc(
-			prometheus.BuildFQName("ntopng", "host", "active_server_flows"),
-			"current number of active server flows for host",
-			hostLabels,
-			nil),
-		bytesRcvd: prometheus.NewDesc(
-			prometheus.BuildFQName("ntopng", "host", "bytes_rcvd"),
-			"number of bytes received for host",
-			hostLabels,
-			nil),
-		bytesSent: prometheus.NewDesc(
-			prometheus.BuildFQName("ntopng", "host", "bytes_sent"),
-			"number of bytes sent for host",
-			hostLabels,
-			nil),
-		DNSQueryTypes: prometheus.NewDesc(
-			prometheus.BuildFQName("ntopng", "host", "dns_queries_by_type"),
-			"total number of DNS queries by record type",
-			DNSQueriesLabels,
-			nil),
-		numAlerts: prometheus.NewDesc(
-			prometheus.BuildFQName("ntopng", "host", "num_alerts"),
-			"number of alerts for host",
-			hostLabels,
-			nil),
-		packetsRcvd: prometheus.NewDesc(
-			prometheus.BuildFQName("ntopng", "host", "packets_rcvd"),
-			"number of packets received for host",
-			hostLabels,
-			nil),
-		packetsSent: prometheus.NewDesc(
-			prometheus.BuildFQName("ntopng", "host", "packets_sent"),
-			"number of packets sent for host",
-			hostLabels,
-			nil),
-		totalAlerts: prometheus.NewDesc(
-			prometheus.BuildFQName("ntopng", "host", "total_alerts"),
-			"total number of alerts for host",
-			hostLabels,
-			nil),
-		totalClientFlows: prometheus.NewDesc(
-			prometheus.BuildFQName("ntopng", "host", "total_client_flows"),
-			"total number of client flows for host",
-			hostLabels,
-			nil),
-		totalDNSQueries: prometheus.NewDesc(
-			prometheus.BuildFQName("ntopng", "host", "total_dns_queries"),
-			"total number of DNS queries for host",
-			basicDNSLabels,
-			nil),
-		totalDNSReplies: prometheus.NewDesc(
-			prometheus.BuildFQName("ntopng", "host", "total_dns_replies"),
-			"total number of DNS replies for host by status",
-			DNSRepliesLabels,
-			nil),
-		totalServerFlows: prometheus.NewDesc(
-			prometheus.BuildFQName("ntopng", "host", "total_server_flows"),
-			"total number of server flows for host",
-			hostLabels,
-			nil),
+		metrics:          metrics,
+		customFields:     customFields,
+		hostInfo: metrics.newDesc("host", "info",
+			"metadata about host from ntopng and the configured enrichment sources, always has a value of 1",
+			infoLabels),
+		activeClientFlows: metrics.newDesc("host", "active_client_flows",
+			"current number of active client flows for host", labels),
+		activeServerFlows: metrics.newDesc("host", "active_server_flows",
+			"current number of active server flows for host", labels),
+		bytesRcvd: metrics.newDesc("host", "bytes_rcvd",
+			"number of bytes received for host", labels),
+		blacklisted: metrics.newDesc("host", "blacklisted",
+			"whether host is on one of the blacklists that ntopng uses", labels),
+		bytesSent: metrics.newDesc("host", "bytes_sent",
+			"number of bytes sent for host", labels),
+		DNSQueryTypes: metrics.newDesc("host", "dns_queries_by_type",
+			"total number of DNS queries by record type", DNSQueriesLabels),
+		duration: metrics.newDesc("host", "duration_seconds",
+			"number of seconds that host has been active for", labels),
+		hostsFiltered: metrics.newDesc("host", "filtered_total",
+			"total number of hosts dropped from scrapes by filter rule", []string{"rule"}),
+		isLocal: metrics.newDesc("host", "is_local",
+			"whether host is on one of the local networks of ntopng", labels),
+		lastScraped: metrics.newDesc("host", "last_seen_timestamp_seconds",
+			"unix timestamp of when ntopng last returned host to the exporter, which is behind the current time for hosts "+
+				"that are retained by hostRetention", labels),
+		misbehavingFlows: metrics.newDesc("host", "misbehaving_flows",
+			"total number of misbehaving flows for host by role", roleLabels),
+		numAlerts: metrics.newDesc("host", "num_alerts",
+			"number of alerts for host", labels),
+		osInfo: metrics.newDesc("host", "os_info",
+			"operating system that ntopng detected for host, always has a value of 1", deepAppend(labels, "os")),
+		packetsRcvd: metrics.newDesc("host", "packets_rcvd",
+			"number of packets received for host", labels),
+		packetsSent: metrics.newDesc("host", "packets_sent",
+			"number of packets sent for host", labels),
+		score: metrics.newDesc("host", "score",
+			"current ntopng score for host by role", roleLabels),
+		seenFirst: metrics.newDesc("host", "first_seen_timestamp_seconds",
+			"unix timestamp of when ntopng first saw host", labels),
+		seenLast: metrics.newDesc("host", "ntopng_last_seen_timestamp_seconds",
+			"unix timestamp of when ntopng last saw traffic from host", labels),
+		tcpPacketStats: metrics.newDesc("host", "tcp_packet_stats",
+			"tcp packet stats for host by direction and type", tcpPacketLabels),
+		throughputBPS: metrics.newDesc("host", "current_throughput_bps",
+			"current throughput of host in bytes per second", labels),
+		throughputPPS: metrics.newDesc("host", "current_throughput_pps",
+			"current throughput of host in packets per second", labels),
+		totalAlerts: metrics.newDesc("host", "total_alerts",
+			"total number of alerts for host", labels),
+		totalClientFlows: metrics.newDesc("host", "total_client_flows",
+			"total number of client flows for host", labels),
+		totalDNSQueries: metrics.newDesc("host", "total_dns_queries",
+			"total number of DNS queries for host", basicDNSLabels),
+		totalDNSReplies: metrics.newDesc("host", "total_dns_replies",
+			"total number of DNS replies for host by status", DNSRepliesLabels),
+		totalServerFlows: metrics.newDesc("host", "total_server_flows",
+			"total number of server flows for host", labels),
+		unreachableFlows: metrics.newDesc("host", "unreachable_flows",
+			"total number of flows to unreachable destinations for host by role", roleLabels),
 	}
 }
 
 func (c *hostCollector) Describe(ch chan<- *prometheus.Desc) {
-	ch <- c.bytesRcvd
-	ch <- c.bytesSent
-	ch <- c.activeClientFlows
-	ch <- c.activeServerFlows
-	ch <- c.totalDNSQueries
-	ch <- c.totalDNSReplies
-	ch <- c.DNSQueryTypes
-	ch <- c.numAlerts
-	ch <- c.totalAlerts
-	ch <- c.totalClientFlows
-	ch <- c.totalServerFlows
+	c.metrics.describe(ch)
 }
 
 func (c *hostCollector) Collect(ch chan<- prometheus.Metric) {
+	sink := c.metrics.sink(ch)
 	c.ntopNGController.ListRWMutex.RLock()
 	defer c.ntopNGController.ListRWMutex.RUnlock()
 	for _, host := range c.ntopNGController.HostList {
-		var hostLabelValues = []string{host.IP, host.IfName, host.MAC, host.Name, strconv.Itoa(host.VLAN)}
-		ch <- prometheus.MustNewConstMetric(c.activeClientFlows, prometheus.GaugeValue, host.ActiveFlowsAsClient,
-			hostLabelValues...)
-		ch <- prometheus.MustNewConstMetric(c.activeServerFlows, prometheus.GaugeValue, host.ActiveFlowsAsServer,
-			hostLabelValues...)
-		ch <- prometheus.MustNewConstMetric(c.bytesSent, prometheus.CounterValue, host.BytesSent, hostLabelValues...)
-		ch <- prometheus.MustNewConstMetric(c.bytesRcvd, prometheus.CounterValue, host.BytesReceived,
-			hostLabelValues...)
-		if !c.config.Metric.ExcludeDNSMetrics {
-			c.outputDNSMetric(ch, "received", &host.DNS.Received, hostLabelValues)
-			c.outputDNSMetric(ch, "sent", &host.DNS.Sent, hostLabelValues)
+		// Retained hosts keep the time of the scrape that their values came from
+		m := sink.at(host.LastScraped)
+		var hostLabelValues = c.hostLabelValues(&host)
+		if c.config.Metric.HostInfoMetric && host.IP != config.OtherHostIP {
+			m.emit(c.hostInfo, prometheus.GaugeValue, 1, c.hostInfoLabelValues(&host)...)
 		}
-		ch <- prometheus.MustNewConstMetric(c.numAlerts, prometheus.GaugeValue, host.NumAlerts,
-			hostLabelValues...)
-		ch <- prometheus.MustNewConstMetric(c.packetsRcvd, prometheus.CounterValue, host.PacketsReceived,
-			hostLabelValues...)
-		ch <- prometheus.MustNewConstMetric(c.packetsSent, prometheus.CounterValue, host.PacketsSent,
-			hostLabelValues...)
-		ch <- prometheus.MustNewConstMetric(c.totalAlerts, prometheus.CounterValue, host.TotalAlerts,
-			hostLabelValues...)
-		ch <- prometheus.MustNewConstMetric(c.totalClientFlows, prometheus.CounterValue, host.TotalFlowsAsClient,
-			hostLabelValues...)
-		ch <- prometheus.MustNewConstMetric(c.totalServerFlows, prometheus.CounterValue, host.TotalFlowsAsServer,
-			hostLabelValues...)
+		m.emit(c.activeClientFlows, prometheus.GaugeValue, host.ActiveFlowsAsClient, hostLabelValues...)
+		m.emit(c.activeServerFlows, prometheus.GaugeValue, host.ActiveFlowsAsServer, hostLabelValues...)
+		m.emit(c.bytesSent, prometheus.CounterValue, host.BytesSent, hostLabelValues...)
+		m.emit(c.bytesRcvd, prometheus.CounterValue, host.BytesReceived, hostLabelValues...)
+		if c.metrics.enabled(c.totalDNSQueries, c.totalDNSReplies, c.DNSQueryTypes) {
+			c.outputDNSMetric(m, "received", &host.DNS.Received, hostLabelValues)
+			c.outputDNSMetric(m, "sent", &host.DNS.Sent, hostLabelValues)
+		}
+		m.emit(c.numAlerts, prometheus.GaugeValue, host.NumAlerts, hostLabelValues...)
+		m.emit(c.packetsRcvd, prometheus.CounterValue, host.PacketsReceived, hostLabelValues...)
+		m.emit(c.packetsSent, prometheus.CounterValue, host.PacketsSent, hostLabelValues...)
+		m.emit(c.totalAlerts, prometheus.CounterValue, host.TotalAlerts, hostLabelValues...)
+		m.emit(c.totalClientFlows, prometheus.CounterValue, host.TotalFlowsAsClient, hostLabelValues...)
+		m.emit(c.totalServerFlows, prometheus.CounterValue, host.TotalFlowsAsServer, hostLabelValues...)
+		m.emit(c.lastScraped, prometheus.GaugeValue, float64(host.LastScraped.Unix()), hostLabelValues...)
+		c.outputExtendedHostMetrics(m, &host, hostLabelValues)
+		for _, customValue := range host.Custom {
+			customField := c.customFields[customValue.Field]
+			m.emit(customField.desc, customField.valueType, customValue.Value,
+				deepAppend(hostLabelValues, customValue.Keys...)...)
+		}
+	}
+	for rule, count := range c.ntopNGController.HostsFiltered {
+		sink.emit(c.hostsFiltered, prometheus.CounterValue, count, rule)
+	}
+}
+
+// hostLabelValues returns the values for the labels that every host metric has, when configured to, the host's name
+// and additional labels are taken from the inventory, and the host's pool is added. In minimal mode only the labels that
+// identify a host are returned, as everything else is carried by the info metric.
+func (c *hostCollector) hostLabelValues(host *ntopng.NtopHost) []string {
+	if c.config.Metric.MinimalHostLabels() {
+		return []string{host.IP, host.IfName, strconv.Itoa(host.VLAN)}
+	}
+	name := host.Name
+	if c.config.Enrichment.Inventory.OverrideLabels && host.Inventory.Hostname != "" {
+		name = host.Inventory.Hostname
+	}
+	labelValues := []string{host.IP, host.IfName, host.MAC, name, strconv.Itoa(host.VLAN)}
+	if c.config.Enrichment.Inventory.OverrideLabels {
+		labelValues = append(labelValues, host.Inventory.Owner, host.Inventory.DeviceType, host.Inventory.Site)
+	}
+	if c.config.Metric.HostPoolLabel {
+		labelValues = append(labelValues, host.Pool)
+	}
+	return labelValues
+}
+
+func (c *hostCollector) hostInfoLabelValues(host *ntopng.NtopHost) []string {
+	infoLabelValues := []string{host.IP, host.IfName, host.MAC, host.Name, strconv.Itoa(host.VLAN), host.Vendor, host.OS}
+	if c.config.Enrichment.Inventory.Enabled() {
+		infoLabelValues = append(infoLabelValues, host.Inventory.Hostname, host.Inventory.Owner,
+			host.Inventory.DeviceType, host.Inventory.Site)
+	}
+	if c.config.Enrichment.GeoIP.InfoLabels {
+		infoLabelValues = append(infoLabelValues, host.Geo.Country, host.Geo.City, host.Geo.ASN, host.Geo.ASOrg)
+	}
+	if c.config.Metric.HostPoolLabel {
+		infoLabelValues = append(infoLabelValues, host.Pool)
+	}
+	return infoLabelValues
+}
+
+// outputExtendedHostMetrics outputs each of the optional groups of host metrics that haven't been disabled in the
+// config. Timestamps, flags and the OS don't make sense for the aggregate "other" host, so they are skipped for it.
+func (c *hostCollector) outputExtendedHostMetrics(m metricSink, host *ntopng.NtopHost,
+	hostLabelValues []string) {
+	if c.metrics.enabled(c.score) {
+		m.emit(c.score, prometheus.GaugeValue, host.ScoreAsClient, deepAppend(hostLabelValues, "client")...)
+		m.emit(c.score, prometheus.GaugeValue, host.ScoreAsServer, deepAppend(hostLabelValues, "server")...)
+	}
+	if c.metrics.enabled(c.throughputBPS, c.throughputPPS) {
+		m.emit(c.throughputBPS, prometheus.GaugeValue, host.ThroughputBPS, hostLabelValues...)
+		m.emit(c.throughputPPS, prometheus.GaugeValue, host.ThroughputPPS, hostLabelValues...)
+	}
+	if c.metrics.enabled(c.tcpPacketStats) {
+		received, sent := deepAppend(hostLabelValues, "received"), deepAppend(hostLabelValues, "sent")
+		m.emit(c.tcpPacketStats, prometheus.CounterValue, host.TCPPacketStatsReceived.Lost,
+			deepAppend(received, "lost")...)
+		m.emit(c.tcpPacketStats, prometheus.CounterValue,
+			host.TCPPacketStatsReceived.OutOfOrder, deepAppend(received, "out_of_order")...)
+		m.emit(c.tcpPacketStats, prometheus.CounterValue,
+			host.TCPPacketStatsReceived.Retransmissions, deepAppend(received, "retransmit")...)
+		m.emit(c.tcpPacketStats, prometheus.CounterValue, host.TCPPacketStatsSent.Lost, deepAppend(sent, "lost")...)
+		m.emit(c.tcpPacketStats, prometheus.CounterValue, host.TCPPacketStatsSent.OutOfOrder,
+			deepAppend(sent, "out_of_order")...)
+		m.emit(c.tcpPacketStats, prometheus.CounterValue,
+			host.TCPPacketStatsSent.Retransmissions, deepAppend(sent, "retransmit")...)
+	}
+	if c.metrics.enabled(c.misbehavingFlows, c.unreachableFlows) {
+		m.emit(c.misbehavingFlows, prometheus.CounterValue, host.MisbehavingFlowsAsClient,
+			deepAppend(hostLabelValues, "client")...)
+		m.emit(c.misbehavingFlows, prometheus.CounterValue, host.MisbehavingFlowsAsServer,
+			deepAppend(hostLabelValues, "server")...)
+		m.emit(c.unreachableFlows, prometheus.CounterValue, host.UnreachableFlowsAsClient,
+			deepAppend(hostLabelValues, "client")...)
+		m.emit(c.unreachableFlows, prometheus.CounterValue, host.UnreachableFlowsAsServer,
+			deepAppend(hostLabelValues, "server")...)
+	}
+	if host.IP == config.OtherHostIP {
+		return
+	}
+	if c.metrics.enabled(c.duration, c.seenFirst, c.seenLast) {
+		m.emit(c.duration, prometheus.GaugeValue, host.Duration, hostLabelValues...)
+		m.emit(c.seenFirst, prometheus.GaugeValue, host.SeenFirst, hostLabelValues...)
+		m.emit(c.seenLast, prometheus.GaugeValue, host.SeenLast, hostLabelValues...)
+	}
+	if c.metrics.enabled(c.blacklisted, c.isLocal) {
+		m.emit(c.blacklisted, prometheus.GaugeValue, boolToFloat(host.Blacklisted), hostLabelValues...)
+		m.emit(c.isLocal, prometheus.GaugeValue, boolToFloat(host.Localhost), hostLabelValues...)
+	}
+	if host.OS != "" {
+		m.emit(c.osInfo, prometheus.GaugeValue, 1, deepAppend(hostLabelValues, host.OS)...)
 	}
 }
 
-func (c *hostCollector) outputDNSMetric(ch chan<- prometheus.Metric, direction string, dns *ntopng.NtopDNSSub,
+func (c *hostCollector) outputDNSMetric(m metricSink, direction string, dns *ntopng.NtopDNSSub,
 	hostLabels []string) {
 	dnsLabels := append(hostLabels, direction)
-	ch <- prometheus.MustNewConstMetric(c.totalDNSQueries, prometheus.CounterValue, dns.NumQueries,
-		dnsLabels...)
-	ch <- prometheus.MustNewConstMetric(c.totalDNSReplies, prometheus.CounterValue, dns.NumRepliesError,
-		deepAppend(dnsLabels, "error")...)
-	ch <- prometheus.MustNewConstMetric(c.totalDNSReplies, prometheus.CounterValue, dns.NumRepliesOK,
-		deepAppend(dnsLabels, "ok")...)
-	ch <- prometheus.MustNewConstMetric(c.DNSQueryTypes, prometheus.CounterValue, dns.Queries.NumA,
-		deepAppend(dnsLabels, "A")...)
-	ch <- prometheus.MustNewConstMetric(c.DNSQueryTypes, prometheus.CounterValue, dns.Queries.NumAAAA,
-		deepAppend(dnsLabels, "AAAA")...)
-	ch <- prometheus.MustNewConstMetric(c.DNSQueryTypes, prometheus.CounterValue, dns.Queries.NumAny,
-		deepAppend(dnsLabels, "ANY")...)
-	ch <- prometheus.MustNewConstMetric(c.DNSQueryTypes, prometheus.CounterValue, dns.Queries.NumCName,
-		deepAppend(dnsLabels, "CNAME")...)
-	ch <- prometheus.MustNewConstMetric(c.DNSQueryTypes, prometheus.CounterValue, dns.Queries.NumMX,
-		deepAppend(dnsLabels, "MX")...)
-	ch <- prometheus.MustNewConstMetric(c.DNSQueryTypes, prometheus.CounterValue, dns.Queries.NumNS,
-		deepAppend(dnsLabels, "NS")...)
-	ch <- prometheus.MustNewConstMetric(c.DNSQueryTypes, prometheus.CounterValue, dns.Queries.NumOther,
-		deepAppend(dnsLabels, "OTHER")...)
-	ch <- prometheus.MustNewConstMetric(c.DNSQueryTypes, prometheus.CounterValue, dns.Queries.NumPTR,
-		deepAppend(dnsLabels, "PTR")...)
-	ch <- prometheus.MustNewConstMetric(c.DNSQueryTypes, prometheus.CounterValue, dns.Queries.NumSOA,
-		deepAppend(dnsLabels, "SOA")...)
-	ch <- prometheus.MustNewConstMetric(c.DNSQueryTypes, prometheus.CounterValue, dns.Queries.NumTXT,
-		deepAppend(dnsLabels, "TXT")...)
+	m.emit(c.totalDNSQueries, prometheus.CounterValue, dns.NumQueries, dnsLabels...)
+	m.emit(c.totalDNSReplies, prometheus.CounterValue, dns.NumRepliesError, deepAppend(dnsLabels, "error")...)
+	m.emit(c.totalDNSReplies, prometheus.CounterValue, dns.NumRepliesOK, deepAppend(dnsLabels, "ok")...)
+	m.emit(c.DNSQueryTypes, prometheus.CounterValue, dns.Queries.NumA, deepAppend(dnsLabels, "A")...)
+	m.emit(c.DNSQueryTypes, prometheus.CounterValue, dns.Queries.NumAAAA, deepAppend(dnsLabels, "AAAA")...)
+	m.emit(c.DNSQueryTypes, prometheus.CounterValue, dns.Queries.NumAny, deepAppend(dnsLabels, "ANY")...)
+	m.emit(c.DNSQueryTypes, prometheus.CounterValue, dns.Queries.NumCName, deepAppend(dnsLabels, "CNAME")...)
+	m.emit(c.DNSQueryTypes, prometheus.CounterValue, dns.Queries.NumMX, deepAppend(dnsLabels, "MX")...)
+	m.emit(c.DNSQueryTypes, prometheus.CounterValue, dns.Queries.NumNS, deepAppend(dnsLabels, "NS")...)
+	m.emit(c.DNSQueryTypes, prometheus.CounterValue, dns.Queries.NumOther, deepAppend(dnsLabels, "OTHER")...)
+	m.emit(c.DNSQueryTypes, prometheus.CounterValue, dns.Queries.NumPTR, deepAppend(dnsLabels, "PTR")...)
+	m.emit(c.DNSQueryTypes, prometheus.CounterValue, dns.Queries.NumSOA, deepAppend(dnsLabels, "SOA")...)
+	m.emit(c.DNSQueryTypes, prometheus.CounterValue, dns.Queries.NumTXT, deepAppend(dnsLabels, "TXT")...)
 }
@@ -0,0 +1,149 @@
+package prometheus
+
+import (
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/aauren/ntopng-exporter/internal/config"
+	"github.com/aauren/ntopng-exporter/internal/ntopng"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+// TestHostNamesMatchConfig makes sure that the lists of built-in host metric families and labels, which the config uses
+// to reject custom host fields that would collide with them, stay in sync with the host collector
+func TestHostNamesMatchConfig(t *testing.T) {
+	tests := []struct {
+		name      string
+		configure func(*config.Config)
+	}{
+		{name: "full host labels", configure: func(*config.Config) {}},
+		{name: "inventory and pool labels", configure: func(c *config.Config) {
+			c.Enrichment.Inventory.OverrideLabels = true
+			c.Metric.HostPoolLabel = true
+		}},
+		{name: "minimal host labels", configure: func(c *config.Config) {
+			c.Metric.HostLabelMode = config.MinimalHostLabelMode
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hostConfig := &config.Config{}
+			setCustomHostFields(t, hostConfig, map[string]any{"field": "ndpi", "path": "*", "name": "ndpi",
+				"keyLabels": []string{"protocol"}})
+			tt.configure(hostConfig)
+			controller := &ntopng.Controller{InvalidMetrics: ntopng.NewInvalidMetricCounter()}
+			families := describedFamilies(t, NewNtopNGHostCollector(controller, hostConfig))
+
+			for family := range families {
+				if family != "host_ndpi" && !slices.Contains(config.HostMetricFamilies, family) {
+					t.Errorf("host metric family %s is missing from config.HostMetricFamilies", family)
+				}
+			}
+			for _, family := range config.HostMetricFamilies {
+				if _, ok := families[family]; !ok {
+					t.Errorf("config.HostMetricFamilies contains %s, which the host collector doesn't describe", family)
+				}
+			}
+			for _, label := range families["host_ndpi"] {
+				if label != "protocol" && !slices.Contains(config.HostLabelNames, label) {
+					t.Errorf("host label %s is missing from config.HostLabelNames", label)
+				}
+			}
+		})
+	}
+}
+
+func TestHostCollector(t *testing.T) {
+	host := func(ip string, name string, bytesSent float64, custom ...ntopng.CustomHostValue) ntopng.NtopHost {
+		return ntopng.NtopHost{IP: ip, IfName: "eth0", MAC: "AA:BB:CC:00:00:01", Name: name, BytesSent: bytesSent,
+			BytesReceived: 2 * bytesSent, Custom: custom}
+	}
+	l7Bytes := func(value float64, keys ...string) ntopng.CustomHostValue {
+		return ntopng.CustomHostValue{Field: 0, Keys: keys, Value: value}
+	}
+	tests := []struct {
+		name  string
+		hosts map[string]ntopng.NtopHost
+		want  string
+		// wantValid is the number of metrics that are still collected when some of them are invalid
+		wantValid   int
+		wantInvalid string
+	}{
+		{
+			name: "hosts",
+			hosts: map[string]ntopng.NtopHost{
+				"10.0.0.1": host("10.0.0.1", "laptop", 100, l7Bytes(60, "HTTP"), l7Bytes(40, "TLS")),
+				"10.0.0.2": host("10.0.0.2", "nas", 1000),
+			},
+			want: `
+# HELP ntopng_host_bytes_rcvd number of bytes received for host
+# TYPE ntopng_host_bytes_rcvd counter
+ntopng_host_bytes_rcvd{ifname="eth0",ip="10.0.0.1",mac="AA:BB:CC:00:00:01",name="laptop",vlan="0"} 200
+ntopng_host_bytes_rcvd{ifname="eth0",ip="10.0.0.2",mac="AA:BB:CC:00:00:01",name="nas",vlan="0"} 2000
+# HELP ntopng_host_bytes_sent number of bytes sent for host
+# TYPE ntopng_host_bytes_sent counter
+ntopng_host_bytes_sent{ifname="eth0",ip="10.0.0.1",mac="AA:BB:CC:00:00:01",name="laptop",vlan="0"} 100
+ntopng_host_bytes_sent{ifname="eth0",ip="10.0.0.2",mac="AA:BB:CC:00:00:01",name="nas",vlan="0"} 1000
+# HELP ntopng_host_l7_bytes bytes by application protocol
+# TYPE ntopng_host_l7_bytes counter
+ntopng_host_l7_bytes{ifname="eth0",ip="10.0.0.1",mac="AA:BB:CC:00:00:01",name="laptop",protocol="HTTP",vlan="0"} 60
+ntopng_host_l7_bytes{ifname="eth0",ip="10.0.0.1",mac="AA:BB:CC:00:00:01",name="laptop",protocol="TLS",vlan="0"} 40
+`,
+		},
+		{
+			name: "duplicate label values",
+			hosts: map[string]ntopng.NtopHost{
+				"10.0.0.1":      host("10.0.0.1", "laptop", 100),
+				"10.0.0.1/copy": host("10.0.0.1", "laptop", 100),
+			},
+			wantValid: 2,
+			wantInvalid: `
+# HELP ntopng_exporter_invalid_metrics_total number of metrics that couldn't be built from the data scraped from ntopng
+# TYPE ntopng_exporter_invalid_metrics_total counter
+ntopng_exporter_invalid_metrics_total{metric="ntopng_host_bytes_rcvd"} 1
+ntopng_exporter_invalid_metrics_total{metric="ntopng_host_bytes_sent"} 1
+`,
+		},
+		{
+			name: "label count mismatch",
+			hosts: map[string]ntopng.NtopHost{
+				"10.0.0.1": host("10.0.0.1", "laptop", 100, l7Bytes(60, "HTTP"), l7Bytes(40, "TLS", "443")),
+			},
+			wantValid: 3,
+			wantInvalid: `
+# HELP ntopng_exporter_invalid_metrics_total number of metrics that couldn't be built from the data scraped from ntopng
+# TYPE ntopng_exporter_invalid_metrics_total counter
+ntopng_exporter_invalid_metrics_total{metric="ntopng_host_l7_bytes"} 1
+`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hostConfig := &config.Config{}
+			hostConfig.Metric.Namespace = config.DefaultMetricNamespace
+			// Only the byte counters are kept, so that the expected output stays readable
+			hostConfig.Metric.Disabled = slices.DeleteFunc(slices.Clone(config.HostMetricFamilies), func(family string) bool {
+				return family == "host_bytes_sent" || family == "host_bytes_rcvd"
+			})
+			setCustomHostFields(t, hostConfig, map[string]any{"field": "ndpi", "path": "*.bytes", "name": "l7_bytes",
+				"type": config.CounterMetricType, "help": "bytes by application protocol", "keyLabels": []string{"protocol"}})
+			controller := &ntopng.Controller{HostList: tt.hosts, InvalidMetrics: ntopng.NewInvalidMetricCounter(),
+				ListRWMutex: &sync.RWMutex{}}
+			collector := NewNtopNGHostCollector(controller, hostConfig)
+
+			if tt.wantInvalid == "" {
+				if err := testutil.CollectAndCompare(collector, strings.NewReader(tt.want)); err != nil {
+					t.Error(err)
+				}
+				return
+			}
+			assertInvalidMetrics(t, collector, tt.wantValid)
+			exporterCollector := NewNtopNGExporterCollector(controller, hostConfig)
+			if err := testutil.CollectAndCompare(exporterCollector, strings.NewReader(tt.wantInvalid)); err != nil {
+				t.Error(err)
+			}
+		})
+	}
+}
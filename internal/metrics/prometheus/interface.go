@@ -1,20 +1,25 @@
 package prometheus
 
 import (
+	"strings"
+
 	"github.com/aauren/ntopng-exporter/internal/config"
 	"github.com/aauren/ntopng-exporter/internal/ntopng"
 	"github.com/prometheus/client_golang/prometheus"
 )
 
 var (
-	interfaceLabels  = []string{"ifname", "ifid"}
-	tcpPacketLabels  = deepAppend(interfaceLabels, "type")
-	throughputLabels = deepAppend(interfaceLabels, "direction")
+	interfaceLabels          = []string{"ifname", "ifid"}
+	interfaceHashLabels      = deepAppend(interfaceLabels, "table")
+	interfaceHashEntryLabels = deepAppend(interfaceHashLabels, "state")
+	tcpPacketLabels          = deepAppend(interfaceLabels, "type")
+	throughputLabels         = deepAppend(interfaceLabels, "direction")
 )
 
 type interfaceCollector struct {
 	ntopNGController    *ntopng.Controller
 	config              *config.Config
+	metrics             *metricSet
 	alertedFlows        *prometheus.Desc
 	alertedFlowsError   *prometheus.Desc
 	alertedFlowsNotice  *prometheus.Desc
@@ -22,6 +27,8 @@ type interfaceCollector struct {
 	bytesRcvd           *prometheus.Desc
 	bytesSent           *prometheus.Desc
 	drops               *prometheus.Desc
+	hashEntries         *prometheus.Desc
+	hashMaxEntries      *prometheus.Desc
 	numDevices          *prometheus.Desc
 	numHosts            *prometheus.Desc
 	numLocalHosts       *prometheus.Desc
@@ -34,155 +41,97 @@ type interfaceCollector struct {
 }
 
 func NewNtopNGInterfaceCollector(ntopController *ntopng.Controller, config *config.Config) *interfaceCollector {
+	metrics := newMetricSet(ntopController, config)
 	return &interfaceCollector{
 		ntopNGController: ntopController,
 		config:           config,
-		alertedFlows: prometheus.NewDesc(
-			prometheus.BuildFQName("ntopng", "interface", "alerted_flows"),
-			"current number of alerted flows client flows",
-			interfaceLabels,
-			nil),
-		alertedFlowsError: prometheus.NewDesc(
-			prometheus.BuildFQName("ntopng", "interface", "alerted_error_flows"),
-			"current number of alerted error flows",
-			interfaceLabels,
-			nil),
-		alertedFlowsNotice: prometheus.NewDesc(
-			prometheus.BuildFQName("ntopng", "interface", "alerted_notice_flows"),
-			"current number of alerted notice flows",
-			interfaceLabels,
-			nil),
-		alertedFlowsWarning: prometheus.NewDesc(
-			prometheus.BuildFQName("ntopng", "interface", "alerted_warning_flows"),
-			"current number of alerted warning flows",
-			interfaceLabels,
-			nil),
-		bytesRcvd: prometheus.NewDesc(
-			prometheus.BuildFQName("ntopng", "interface", "bytes_rcvd"),
-			"total number of bytes received",
-			interfaceLabels,
-			nil),
-		bytesSent: prometheus.NewDesc(
-			prometheus.BuildFQName("ntopng", "interface", "bytes_sent"),
-			"total number of bytes sent",
-			interfaceLabels,
-			nil),
-		drops: prometheus.NewDesc(
-			prometheus.BuildFQName("ntopng", "interface", "drops"),
-			"number of drops",
-			interfaceLabels,
-			nil),
-		numDevices: prometheus.NewDesc(
-			prometheus.BuildFQName("ntopng", "interface", "num_devices"),
-			"number of devices",
-			interfaceLabels,
-			nil),
-		numHosts: prometheus.NewDesc(
-			prometheus.BuildFQName("ntopng", "interface", "num_hosts"),
-			"number of hosts",
-			interfaceLabels,
-			nil),
-		numLocalHosts: prometheus.NewDesc(
-			prometheus.BuildFQName("ntopng", "interface", "num_local_hosts"),
-			"number of hosts on the local network",
-			interfaceLabels,
-			nil),
-		packetsRcvd: prometheus.NewDesc(
-			prometheus.BuildFQName("ntopng", "interface", "packets_rcvd"),
-			"total number of packets received",
-			interfaceLabels,
-			nil),
-		packetsSent: prometheus.NewDesc(
-			prometheus.BuildFQName("ntopng", "interface", "packets_sent"),
-			"total number of packets sent",
-			interfaceLabels,
-			nil),
-		speed: prometheus.NewDesc(
-			prometheus.BuildFQName("ntopng", "interface", "speed"),
-			"current speed of interface in Mbps",
-			interfaceLabels,
-			nil),
-		tcpPacketStats: prometheus.NewDesc(
-			prometheus.BuildFQName("ntopng", "interface", "tcp_packet_stats"),
-			"tcp packet stats by type",
-			tcpPacketLabels,
-			nil),
-		throughputBPS: prometheus.NewDesc(
-			prometheus.BuildFQName("ntopng", "interface", "current_throughput_bps"),
-			"current throughput by direction in bytes per second",
-			throughputLabels,
-			nil),
-		throughputPPS: prometheus.NewDesc(
-			prometheus.BuildFQName("ntopng", "interface", "current_throughput_pps"),
-			"current throughput by direction in packets per second",
-			throughputLabels,
-			nil),
+		metrics:          metrics,
+		alertedFlows: metrics.newDesc("interface", "alerted_flows",
+			"current number of alerted flows client flows", interfaceLabels),
+		alertedFlowsError: metrics.newDesc("interface", "alerted_error_flows",
+			"current number of alerted error flows", interfaceLabels),
+		alertedFlowsNotice: metrics.newDesc("interface", "alerted_notice_flows",
+			"current number of alerted notice flows", interfaceLabels),
+		alertedFlowsWarning: metrics.newDesc("interface", "alerted_warning_flows",
+			"current number of alerted warning flows", interfaceLabels),
+		bytesRcvd: metrics.newDesc("interface", "bytes_rcvd",
+			"total number of bytes received", interfaceLabels),
+		bytesSent: metrics.newDesc("interface", "bytes_sent",
+			"total number of bytes sent", interfaceLabels),
+		drops: metrics.newDesc("interface", "drops",
+			"number of drops", interfaceLabels),
+		hashEntries: metrics.newDesc("interface", "hash_entries",
+			"current number of entries in hash table by entry state", interfaceHashEntryLabels),
+		hashMaxEntries: metrics.newDesc("interface", "hash_max_entries",
+			"configured maximum number of entries in hash table", interfaceHashLabels),
+		numDevices: metrics.newDesc("interface", "num_devices",
+			"number of devices", interfaceLabels),
+		numHosts: metrics.newDesc("interface", "num_hosts",
+			"number of hosts", interfaceLabels),
+		numLocalHosts: metrics.newDesc("interface", "num_local_hosts",
+			"number of hosts on the local network", interfaceLabels),
+		packetsRcvd: metrics.newDesc("interface", "packets_rcvd",
+			"total number of packets received", interfaceLabels),
+		packetsSent: metrics.newDesc("interface", "packets_sent",
+			"total number of packets sent", interfaceLabels),
+		speed: metrics.newDesc("interface", "speed",
+			"current speed of interface in Mbps", interfaceLabels),
+		tcpPacketStats: metrics.newDesc("interface", "tcp_packet_stats",
+			"tcp packet stats by type", tcpPacketLabels),
+		throughputBPS: metrics.newDesc("interface", "current_throughput_bps",
+			"current throughput by direction in bytes per second", throughputLabels),
+		throughputPPS: metrics.newDesc("interface", "current_throughput_pps",
+			"current throughput by direction in packets per second", throughputLabels),
 	}
 }
 
 func (c *interfaceCollector) Describe(ch chan<- *prometheus.Desc) {
-	ch <- c.alertedFlows
-	ch <- c.alertedFlowsError
-	ch <- c.alertedFlowsNotice
-	ch <- c.alertedFlowsWarning
-	ch <- c.bytesRcvd
-	ch <- c.bytesSent
-	ch <- c.drops
-	ch <- c.numDevices
-	ch <- c.numHosts
-	ch <- c.numLocalHosts
-	ch <- c.packetsRcvd
-	ch <- c.packetsSent
-	ch <- c.speed
-	ch <- c.tcpPacketStats
-	ch <- c.throughputBPS
-	ch <- c.throughputPPS
+	c.metrics.describe(ch)
 }
 
 func (c *interfaceCollector) Collect(ch chan<- prometheus.Metric) {
+	sink := c.metrics.sink(ch)
 	c.ntopNGController.ListRWMutex.RLock()
 	defer c.ntopNGController.ListRWMutex.RUnlock()
 	for _, myIf := range c.ntopNGController.InterfaceList {
+		m := sink.at(c.ntopNGController.ScrapeTimes[config.InterfaceScrape][myIf.IfName])
 		var interfaceLabelValues = []string{myIf.IfName, myIf.IfID}
-		ch <- prometheus.MustNewConstMetric(c.alertedFlows, prometheus.GaugeValue, myIf.AlertedFlows,
-			interfaceLabelValues...)
-		ch <- prometheus.MustNewConstMetric(c.alertedFlowsError, prometheus.GaugeValue, myIf.AlertedFlowsError,
-			interfaceLabelValues...)
-		ch <- prometheus.MustNewConstMetric(c.alertedFlowsNotice, prometheus.GaugeValue, myIf.AlertedFlowsNotice,
-			interfaceLabelValues...)
-		ch <- prometheus.MustNewConstMetric(c.alertedFlowsWarning, prometheus.GaugeValue, myIf.AlertedFlowsWarning,
-			interfaceLabelValues...)
-		ch <- prometheus.MustNewConstMetric(c.bytesRcvd, prometheus.CounterValue, myIf.BytesReceived,
-			interfaceLabelValues...)
-		ch <- prometheus.MustNewConstMetric(c.bytesSent, prometheus.CounterValue, myIf.BytesSent,
-			interfaceLabelValues...)
-		ch <- prometheus.MustNewConstMetric(c.drops, prometheus.CounterValue, myIf.Drops,
-			interfaceLabelValues...)
-		ch <- prometheus.MustNewConstMetric(c.numDevices, prometheus.GaugeValue, myIf.NumDevices,
-			interfaceLabelValues...)
-		ch <- prometheus.MustNewConstMetric(c.numHosts, prometheus.GaugeValue, myIf.NumHosts,
-			interfaceLabelValues...)
-		ch <- prometheus.MustNewConstMetric(c.numLocalHosts, prometheus.GaugeValue, myIf.NumLocalHosts,
-			interfaceLabelValues...)
-		ch <- prometheus.MustNewConstMetric(c.packetsRcvd, prometheus.CounterValue, myIf.PacketsReceived,
-			interfaceLabelValues...)
-		ch <- prometheus.MustNewConstMetric(c.packetsSent, prometheus.CounterValue, myIf.PacketsSent,
-			interfaceLabelValues...)
-		ch <- prometheus.MustNewConstMetric(c.speed, prometheus.GaugeValue, myIf.Speed,
-			interfaceLabelValues...)
-		ch <- prometheus.MustNewConstMetric(c.tcpPacketStats, prometheus.CounterValue, myIf.TCPPacketStats.Lost,
+		m.emit(c.alertedFlows, prometheus.GaugeValue, myIf.AlertedFlows, interfaceLabelValues...)
+		m.emit(c.alertedFlowsError, prometheus.GaugeValue, myIf.AlertedFlowsError, interfaceLabelValues...)
+		m.emit(c.alertedFlowsNotice, prometheus.GaugeValue, myIf.AlertedFlowsNotice, interfaceLabelValues...)
+		m.emit(c.alertedFlowsWarning, prometheus.GaugeValue, myIf.AlertedFlowsWarning, interfaceLabelValues...)
+		m.emit(c.bytesRcvd, prometheus.CounterValue, myIf.BytesReceived, interfaceLabelValues...)
+		m.emit(c.bytesSent, prometheus.CounterValue, myIf.BytesSent, interfaceLabelValues...)
+		m.emit(c.drops, prometheus.CounterValue, myIf.Drops, interfaceLabelValues...)
+		m.emit(c.numDevices, prometheus.GaugeValue, myIf.NumDevices, interfaceLabelValues...)
+		m.emit(c.numHosts, prometheus.GaugeValue, myIf.NumHosts, interfaceLabelValues...)
+		m.emit(c.numLocalHosts, prometheus.GaugeValue, myIf.NumLocalHosts, interfaceLabelValues...)
+		m.emit(c.packetsRcvd, prometheus.CounterValue, myIf.PacketsReceived, interfaceLabelValues...)
+		m.emit(c.packetsSent, prometheus.CounterValue, myIf.PacketsSent, interfaceLabelValues...)
+		m.emit(c.speed, prometheus.GaugeValue, myIf.Speed, interfaceLabelValues...)
+		m.emit(c.tcpPacketStats, prometheus.CounterValue, myIf.TCPPacketStats.Lost,
 			deepAppend(interfaceLabelValues, "lost")...)
-		ch <- prometheus.MustNewConstMetric(c.tcpPacketStats, prometheus.CounterValue, myIf.TCPPacketStats.OutOfOrder,
+		m.emit(c.tcpPacketStats, prometheus.CounterValue, myIf.TCPPacketStats.OutOfOrder,
 			deepAppend(interfaceLabelValues, "out_of_order")...)
-		ch <- prometheus.MustNewConstMetric(c.tcpPacketStats, prometheus.CounterValue, myIf.TCPPacketStats.Retransmissions,
+		m.emit(c.tcpPacketStats, prometheus.CounterValue, myIf.TCPPacketStats.Retransmissions,
 			deepAppend(interfaceLabelValues, "retransmit")...)
-		ch <- prometheus.MustNewConstMetric(c.throughputBPS, prometheus.GaugeValue, myIf.Throughput.Download.BPS,
+		m.emit(c.throughputBPS, prometheus.GaugeValue, myIf.Throughput.Download.BPS,
 			deepAppend(interfaceLabelValues, "received")...)
-		ch <- prometheus.MustNewConstMetric(c.throughputBPS, prometheus.GaugeValue, myIf.Throughput.Upload.BPS,
+		m.emit(c.throughputBPS, prometheus.GaugeValue, myIf.Throughput.Upload.BPS,
 			deepAppend(interfaceLabelValues, "sent")...)
-		ch <- prometheus.MustNewConstMetric(c.throughputPPS, prometheus.GaugeValue, myIf.Throughput.Download.PPS,
+		m.emit(c.throughputPPS, prometheus.GaugeValue, myIf.Throughput.Download.PPS,
 			deepAppend(interfaceLabelValues, "received")...)
-		ch <- prometheus.MustNewConstMetric(c.throughputPPS, prometheus.GaugeValue, myIf.Throughput.Download.PPS,
+		m.emit(c.throughputPPS, prometheus.GaugeValue, myIf.Throughput.Download.PPS,
 			deepAppend(interfaceLabelValues, "sent")...)
+		for table, stats := range myIf.HashTables {
+			hashTableLabelValues := deepAppend(interfaceLabelValues, table)
+			for state, count := range stats.EntryStates {
+				m.emit(c.hashEntries, prometheus.GaugeValue, count,
+					deepAppend(hashTableLabelValues, strings.TrimPrefix(state, "hash_entry_state_"))...)
+			}
+			if stats.MaxEntries > 0 {
+				m.emit(c.hashMaxEntries, prometheus.GaugeValue, stats.MaxEntries, hashTableLabelValues...)
+			}
+		}
 	}
 }
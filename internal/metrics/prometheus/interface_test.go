@@ -0,0 +1,90 @@
+package prometheus
+
+import (
+	"encoding/json"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/aauren/ntopng-exporter/internal/config"
+	"github.com/aauren/ntopng-exporter/internal/ntopng"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestInterfaceCollector(t *testing.T) {
+	tests := []struct {
+		name string
+		// fixture holds the interface list in the form that ntopng returns each interface in, keyed by interface name
+		fixture string
+		want    string
+		// wantValid is the number of metrics that are still collected when some of them are invalid
+		wantValid   int
+		wantInvalid string
+	}{
+		{
+			name:    "interfaces",
+			fixture: "testdata/interfaces.json",
+			want: `
+# HELP ntopng_interface_bytes_rcvd total number of bytes received
+# TYPE ntopng_interface_bytes_rcvd counter
+ntopng_interface_bytes_rcvd{ifid="0",ifname="eth0"} 100000
+ntopng_interface_bytes_rcvd{ifid="1",ifname="eth1"} 2000
+# HELP ntopng_interface_bytes_sent total number of bytes sent
+# TYPE ntopng_interface_bytes_sent counter
+ntopng_interface_bytes_sent{ifid="0",ifname="eth0"} 50000
+ntopng_interface_bytes_sent{ifid="1",ifname="eth1"} 1000
+# HELP ntopng_interface_tcp_packet_stats tcp packet stats by type
+# TYPE ntopng_interface_tcp_packet_stats counter
+ntopng_interface_tcp_packet_stats{ifid="0",ifname="eth0",type="lost"} 3
+ntopng_interface_tcp_packet_stats{ifid="0",ifname="eth0",type="out_of_order"} 2
+ntopng_interface_tcp_packet_stats{ifid="0",ifname="eth0",type="retransmit"} 1
+ntopng_interface_tcp_packet_stats{ifid="1",ifname="eth1",type="lost"} 0
+ntopng_interface_tcp_packet_stats{ifid="1",ifname="eth1",type="out_of_order"} 0
+ntopng_interface_tcp_packet_stats{ifid="1",ifname="eth1",type="retransmit"} 0
+`,
+		},
+		{
+			name:      "duplicate label values",
+			fixture:   "testdata/duplicate_interfaces.json",
+			wantValid: 5,
+			wantInvalid: `
+# HELP ntopng_exporter_invalid_metrics_total number of metrics that couldn't be built from the data scraped from ntopng
+# TYPE ntopng_exporter_invalid_metrics_total counter
+ntopng_exporter_invalid_metrics_total{metric="ntopng_interface_bytes_rcvd"} 1
+ntopng_exporter_invalid_metrics_total{metric="ntopng_interface_bytes_sent"} 1
+ntopng_exporter_invalid_metrics_total{metric="ntopng_interface_tcp_packet_stats"} 3
+`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			interfaceConfig := &config.Config{}
+			interfaceConfig.Metric.Namespace = config.DefaultMetricNamespace
+			// Only the byte counters and TCP packet stats are kept, so that the expected output stays readable
+			interfaceConfig.Metric.Disabled = []string{"interface_[^bt]*"}
+			controller := &ntopng.Controller{InvalidMetrics: ntopng.NewInvalidMetricCounter(),
+				ListRWMutex: &sync.RWMutex{}}
+			fixture, err := os.ReadFile(tt.fixture)
+			if err != nil {
+				t.Fatalf("was not able to read %s: %v", tt.fixture, err)
+			}
+			if err := json.Unmarshal(fixture, &controller.InterfaceList); err != nil {
+				t.Fatalf("was not able to parse %s: %v", tt.fixture, err)
+			}
+			collector := NewNtopNGInterfaceCollector(controller, interfaceConfig)
+
+			if tt.wantInvalid == "" {
+				if err := testutil.CollectAndCompare(collector, strings.NewReader(tt.want)); err != nil {
+					t.Error(err)
+				}
+				return
+			}
+			assertInvalidMetrics(t, collector, tt.wantValid)
+			exporterCollector := NewNtopNGExporterCollector(controller, interfaceConfig)
+			if err := testutil.CollectAndCompare(exporterCollector, strings.NewReader(tt.wantInvalid)); err != nil {
+				t.Error(err)
+			}
+		})
+	}
+}
@@ -0,0 +1,85 @@
+package prometheus
+
+import (
+	"github.com/aauren/ntopng-exporter/internal/config"
+	"github.com/aauren/ntopng-exporter/internal/ntopng"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	macLabels    = []string{"mac", "ifname", "manufacturer"}
+	macARPLabels = deepAppend(macLabels, "direction")
+)
+
+type macCollector struct {
+	ntopNGController *ntopng.Controller
+	config           *config.Config
+	metrics          *metricSet
+	arpReplies       *prometheus.Desc
+	arpRequests      *prometheus.Desc
+	bytesRcvd        *prometheus.Desc
+	bytesSent        *prometheus.Desc
+	macsFiltered     *prometheus.Desc
+	numHosts         *prometheus.Desc
+	packetsRcvd      *prometheus.Desc
+	packetsSent      *prometheus.Desc
+	seenFirst        *prometheus.Desc
+	seenLast         *prometheus.Desc
+}
+
+func NewNtopNGMACCollector(ntopController *ntopng.Controller, config *config.Config) *macCollector {
+	metrics := newMetricSet(ntopController, config)
+	return &macCollector{
+		ntopNGController: ntopController,
+		config:           config,
+		metrics:          metrics,
+		arpReplies: metrics.newDesc("mac", "arp_replies",
+			"number of ARP replies by direction for layer 2 device", macARPLabels),
+		arpRequests: metrics.newDesc("mac", "arp_requests",
+			"number of ARP requests by direction for layer 2 device", macARPLabels),
+		bytesRcvd: metrics.newDesc("mac", "bytes_rcvd",
+			"number of bytes received for layer 2 device", macLabels),
+		bytesSent: metrics.newDesc("mac", "bytes_sent",
+			"number of bytes sent for layer 2 device", macLabels),
+		macsFiltered: metrics.newDesc("mac", "filtered_total",
+			"total number of layer 2 devices dropped from scrapes by filter rule", []string{"rule"}),
+		numHosts: metrics.newDesc("mac", "num_hosts",
+			"number of IP hosts seen behind layer 2 device", macLabels),
+		packetsRcvd: metrics.newDesc("mac", "packets_rcvd",
+			"number of packets received for layer 2 device", macLabels),
+		packetsSent: metrics.newDesc("mac", "packets_sent",
+			"number of packets sent for layer 2 device", macLabels),
+		seenFirst: metrics.newDesc("mac", "first_seen_timestamp_seconds",
+			"unix timestamp of when ntopng first saw layer 2 device", macLabels),
+		seenLast: metrics.newDesc("mac", "last_seen_timestamp_seconds",
+			"unix timestamp of when ntopng last saw layer 2 device", macLabels),
+	}
+}
+
+func (c *macCollector) Describe(ch chan<- *prometheus.Desc) {
+	c.metrics.describe(ch)
+}
+
+func (c *macCollector) Collect(ch chan<- prometheus.Metric) {
+	m := c.metrics.sink(ch)
+	c.ntopNGController.ListRWMutex.RLock()
+	defer c.ntopNGController.ListRWMutex.RUnlock()
+	for _, mac := range c.ntopNGController.MACList {
+		var macLabelValues = []string{mac.MAC, mac.IfName, mac.Manufacturer}
+		m.emit(c.arpReplies, prometheus.CounterValue, mac.ARPRepliesReceived, deepAppend(macLabelValues, "received")...)
+		m.emit(c.arpReplies, prometheus.CounterValue, mac.ARPRepliesSent, deepAppend(macLabelValues, "sent")...)
+		m.emit(c.arpRequests, prometheus.CounterValue, mac.ARPRequestsReceived,
+			deepAppend(macLabelValues, "received")...)
+		m.emit(c.arpRequests, prometheus.CounterValue, mac.ARPRequestsSent, deepAppend(macLabelValues, "sent")...)
+		m.emit(c.bytesRcvd, prometheus.CounterValue, mac.BytesReceived, macLabelValues...)
+		m.emit(c.bytesSent, prometheus.CounterValue, mac.BytesSent, macLabelValues...)
+		m.emit(c.numHosts, prometheus.GaugeValue, mac.NumHosts, macLabelValues...)
+		m.emit(c.packetsRcvd, prometheus.CounterValue, mac.PacketsReceived, macLabelValues...)
+		m.emit(c.packetsSent, prometheus.CounterValue, mac.PacketsSent, macLabelValues...)
+		m.emit(c.seenFirst, prometheus.GaugeValue, mac.SeenFirst, macLabelValues...)
+		m.emit(c.seenLast, prometheus.GaugeValue, mac.SeenLast, macLabelValues...)
+	}
+	for rule, count := range c.ntopNGController.MACsFiltered {
+		m.emit(c.macsFiltered, prometheus.CounterValue, count, rule)
+	}
+}
@@ -0,0 +1,196 @@
+package prometheus
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/aauren/ntopng-exporter/internal/config"
+	"github.com/aauren/ntopng-exporter/internal/ntopng"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// metricSet creates the descriptors of a collector and emits its metrics, applying the configured namespace, constant
+// labels, disabled metric families and relabel rules to both
+type metricSet struct {
+	config         *config.Config
+	invalidMetrics *ntopng.InvalidMetricCounter
+	namespace      string
+	constLabels    prometheus.Labels
+	rules          []relabelRule
+	// descs holds every descriptor created by the set whose metric family hasn't been dropped
+	descs    []*prometheus.Desc
+	relabels map[*prometheus.Desc]*descRelabel
+}
+
+type relabelRule struct {
+	action      string
+	metrics     *regexp.Regexp
+	label       string
+	targetLabel string
+	regex       *regexp.Regexp
+	replacement string
+}
+
+// descRelabel is what needs to be done to the metrics of a single descriptor when they are emitted
+type descRelabel struct {
+	name     string
+	dropped  bool
+	replaces []labelReplace
+}
+
+// labelReplace replaces the value of the label at index, if it matches regex
+type labelReplace struct {
+	index       int
+	regex       *regexp.Regexp
+	replacement string
+}
+
+// metricSink emits the metrics of a single collection
+type metricSink struct {
+	set *metricSet
+	ch  chan<- prometheus.Metric
+	// emitted holds the series that have already been emitted during the collection, so that duplicates can be caught
+	// before they fail the whole scrape
+	emitted map[string]bool
+	// timestamp is given to every metric that is emitted, unless it is zero
+	timestamp time.Time
+}
+
+func newMetricSet(ntopController *ntopng.Controller, config *config.Config) *metricSet {
+	set := &metricSet{
+		config:         config,
+		invalidMetrics: ntopController.InvalidMetrics,
+		namespace:      config.Metric.Namespace,
+		constLabels:    config.Metric.ConstLabels,
+		relabels:       make(map[*prometheus.Desc]*descRelabel),
+	}
+	for _, rule := range config.Metric.Relabel {
+		// Regexes are anchored the same way that Prometheus anchors those of its relabel configs
+		compiledRule := relabelRule{
+			action:      rule.Action,
+			metrics:     regexp.MustCompile("^(?:" + rule.Metrics + ")$"),
+			label:       rule.Label,
+			targetLabel: rule.TargetLabel,
+			replacement: rule.Replacement,
+		}
+		if rule.Regex != "" {
+			compiledRule.regex = regexp.MustCompile("^(?:" + rule.Regex + ")$")
+		}
+		set.rules = append(set.rules, compiledRule)
+	}
+	return set
+}
+
+// newDesc creates the descriptor of a metric family named namespace_subsystem_name, families that are disabled are
+// dropped, and the relabel rules that match the name are applied to its label names and remembered for when its metrics
+// are emitted
+func (s *metricSet) newDesc(subsystem, name, help string, labels []string) *prometheus.Desc {
+	fqName := prometheus.BuildFQName(s.namespace, subsystem, name)
+	labels = deepAppend(labels)
+	relabel := &descRelabel{name: fqName,
+		dropped: s.config.Metric.IsMetricDisabled(prometheus.BuildFQName("", subsystem, name))}
+	for _, rule := range s.rules {
+		if relabel.dropped {
+			break
+		}
+		matches := rule.metrics.MatchString(fqName)
+		if (rule.action == config.DropRelabelAction && matches) || (rule.action == config.KeepRelabelAction && !matches) {
+			relabel.dropped = true
+			break
+		}
+		if !matches {
+			continue
+		}
+		for i, label := range labels {
+			if label != rule.label {
+				continue
+			}
+			switch rule.action {
+			case config.RenameRelabelAction:
+				labels[i] = rule.targetLabel
+			case config.ReplaceRelabelAction:
+				relabel.replaces = append(relabel.replaces, labelReplace{index: i, regex: rule.regex,
+					replacement: rule.replacement})
+			}
+		}
+	}
+	desc := prometheus.NewDesc(fqName, help, labels, s.constLabels)
+	s.relabels[desc] = relabel
+	if !relabel.dropped {
+		s.descs = append(s.descs, desc)
+	}
+	return desc
+}
+
+// describe sends the descriptors of all of the metric families that haven't been dropped
+func (s *metricSet) describe(ch chan<- *prometheus.Desc) {
+	for _, desc := range s.descs {
+		ch <- desc
+	}
+}
+
+// enabled returns true if the metric family of at least one of the descriptors hasn't been dropped, which allows
+// collectors to skip preparing the metrics of dropped families
+func (s *metricSet) enabled(descs ...*prometheus.Desc) bool {
+	for _, desc := range descs {
+		if !s.relabels[desc].dropped {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *metricSet) sink(ch chan<- prometheus.Metric) metricSink {
+	return metricSink{set: s, ch: ch, emitted: make(map[string]bool)}
+}
+
+// at returns a sink that timestamps its metrics with the time that ntopng was scraped, so that they line up with when
+// ntopng produced them rather than with when Prometheus collected them. Unless sample timestamps are enabled, or if the
+// scrape time isn't known, metrics are left without a timestamp.
+func (m metricSink) at(scrapeTime time.Time) metricSink {
+	if m.set.config.Metric.SampleTimestamps {
+		m.timestamp = scrapeTime
+	}
+	return m
+}
+
+// emit sends a metric for desc after applying the relabel rules to its label values, metrics of dropped families are
+// discarded. Metrics that can't be built, or that have already been emitted with the same label values, are sent as invalid metrics and
+// counted instead, so that a single bad entry doesn't fail the whole scrape.
+func (m metricSink) emit(desc *prometheus.Desc, valueType prometheus.ValueType, value float64, labelValues ...string) {
+	relabel := m.set.relabels[desc]
+	if relabel.dropped {
+		return
+	}
+	if len(relabel.replaces) > 0 {
+		labelValues = deepAppend(labelValues)
+		for _, replace := range relabel.replaces {
+			labelValues[replace.index] = replace.regex.ReplaceAllString(labelValues[replace.index], replace.replacement)
+		}
+	}
+	series := relabel.name + "\x00" + strings.Join(labelValues, "\x00")
+	if m.emitted[series] {
+		m.invalid(desc, relabel.name, fmt.Errorf("metric %s was already emitted with label values %v", relabel.name,
+			labelValues))
+		return
+	}
+	m.emitted[series] = true
+	metric, err := prometheus.NewConstMetric(desc, valueType, value, labelValues...)
+	if err != nil {
+		m.invalid(desc, relabel.name, err)
+		return
+	}
+	if !m.timestamp.IsZero() {
+		metric = prometheus.NewMetricWithTimestamp(m.timestamp, metric)
+	}
+	m.ch <- metric
+}
+
+// invalid sends an invalid metric for desc, which makes the error show up in the log of the metrics handler, and counts
+// it against the metric family
+func (m metricSink) invalid(desc *prometheus.Desc, name string, err error) {
+	m.set.invalidMetrics.Add(name)
+	m.ch <- prometheus.NewInvalidMetric(desc, err)
+}
@@ -0,0 +1,174 @@
+package prometheus
+
+import (
+	"regexp"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/aauren/ntopng-exporter/internal/config"
+	"github.com/aauren/ntopng-exporter/internal/ntopng"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/spf13/viper"
+)
+
+var descStringRegex = regexp.MustCompile(`^Desc\{fqName: "([^"]*)", .*variableLabels: \{([^}]*)\}\}$`)
+
+// describedFamilies returns the variable labels of every metric family that the collector describes, keyed by name
+func describedFamilies(t *testing.T, collector prometheus.Collector) map[string][]string {
+	t.Helper()
+	ch := make(chan *prometheus.Desc)
+	go func() {
+		collector.Describe(ch)
+		close(ch)
+	}()
+	families := make(map[string][]string)
+	for desc := range ch {
+		match := descStringRegex.FindStringSubmatch(desc.String())
+		if match == nil {
+			t.Fatalf("was not able to parse descriptor: %s", desc)
+		}
+		var labels []string
+		if match[2] != "" {
+			labels = strings.Split(match[2], ",")
+		}
+		families[match[1]] = labels
+	}
+	return families
+}
+
+// setCustomHostFields unmarshals the custom host fields into the config the same way that the config file is, as their
+// type isn't exported
+func setCustomHostFields(t *testing.T, c *config.Config, fields ...map[string]any) {
+	t.Helper()
+	customFields := viper.New()
+	customFields.Set("fields", fields)
+	if err := customFields.UnmarshalKey("fields", &c.Metric.CustomHostFields); err != nil {
+		t.Fatalf("was not able to unmarshal custom host fields: %v", err)
+	}
+}
+
+// assertInvalidMetrics gathers the metrics of the collector, which must fail because some of them are invalid, and
+// checks that the rest of the metrics are still gathered
+func assertInvalidMetrics(t *testing.T, collector prometheus.Collector, wantValid int) {
+	t.Helper()
+	registry := prometheus.NewPedanticRegistry()
+	registry.MustRegister(collector)
+	families, err := registry.Gather()
+	if err == nil {
+		t.Errorf("Gather() didn't return an error for the invalid metrics")
+	}
+	valid := 0
+	for _, family := range families {
+		valid += len(family.GetMetric())
+	}
+	if valid != wantValid {
+		t.Errorf("Gather() returned %d valid metrics, want %d", valid, wantValid)
+	}
+}
+
+// allLabelsConfig returns a config with all of the optional labels of the host collector enabled
+func allLabelsConfig() *config.Config {
+	builtInConfig := &config.Config{}
+	builtInConfig.Enrichment.Inventory.Path = "inventory.csv"
+	builtInConfig.Enrichment.Inventory.OverrideLabels = true
+	builtInConfig.Enrichment.GeoIP.InfoLabels = true
+	builtInConfig.Metric.HostPoolLabel = true
+	return builtInConfig
+}
+
+// builtInCollectors returns every built-in collector, built with builtInConfig
+func builtInCollectors(builtInConfig *config.Config) []prometheus.Collector {
+	controller := &ntopng.Controller{InvalidMetrics: ntopng.NewInvalidMetricCounter()}
+	return []prometheus.Collector{
+		NewNtopNGASStatsCollector(controller, builtInConfig),
+		NewNtopNGCountryStatsCollector(controller, builtInConfig),
+		NewNtopNGExporterCollector(controller, builtInConfig),
+		NewNtopNGFlowDeviceCollector(controller, builtInConfig),
+		NewNtopNGHostCollector(controller, builtInConfig),
+		NewNtopNGInterfaceCollector(controller, builtInConfig),
+		NewNtopNGMACCollector(controller, builtInConfig),
+		NewNtopNGNetworkCollector(controller, builtInConfig),
+		NewNtopNGOSStatsCollector(controller, builtInConfig),
+		NewNtopNGPoolCollector(controller, builtInConfig),
+		NewNtopNGSNMPCollector(controller, builtInConfig),
+		NewNtopNGSystemCollector(controller, builtInConfig),
+		NewNtopNGSubnetCollector(controller, builtInConfig),
+		NewNtopNGVLANCollector(controller, builtInConfig),
+		NewNtopNGCountryCollector(controller, builtInConfig),
+		NewNtopNGASNCollector(controller, builtInConfig),
+	}
+}
+
+// TestBuiltInMetricPrefixesMatchConfig makes sure that every built-in metric family starts with one of the prefixes
+// that the config keeps custom endpoints from using
+func TestBuiltInMetricPrefixesMatchConfig(t *testing.T) {
+	for _, collector := range builtInCollectors(allLabelsConfig()) {
+		for family := range describedFamilies(t, collector) {
+			covered := false
+			for _, prefix := range config.BuiltInMetricPrefixes {
+				if family == prefix || strings.HasPrefix(family, prefix+"_") {
+					covered = true
+					break
+				}
+			}
+			if !covered {
+				t.Errorf("metric family %s doesn't start with any of config.BuiltInMetricPrefixes", family)
+			}
+		}
+	}
+}
+
+// TestBuiltInMetricPrefixesAreUnique makes sure that no two built-in collectors share a subsystem, so that a metric
+// family added to one of them can't collide with a family of the other
+func TestBuiltInMetricPrefixesAreUnique(t *testing.T) {
+	usedBy := make(map[string]int)
+	for i, collector := range builtInCollectors(allLabelsConfig()) {
+		for family := range describedFamilies(t, collector) {
+			// Prefixes can themselves contain underscores, so the longest matching prefix is the subsystem
+			subsystem := ""
+			for _, prefix := range config.BuiltInMetricPrefixes {
+				if (family == prefix || strings.HasPrefix(family, prefix+"_")) && len(prefix) > len(subsystem) {
+					subsystem = prefix
+				}
+			}
+			if other, ok := usedBy[subsystem]; ok && other != i {
+				t.Errorf("metric family %s uses the %s subsystem of built-in collector %d", family, subsystem, other)
+			}
+			usedBy[subsystem] = i
+		}
+	}
+}
+
+// TestReservedLabelNamesMatchConfig makes sure that every label of the built-in metric families is one of the labels
+// that the config keeps constant labels and rename rules from using
+func TestReservedLabelNamesMatchConfig(t *testing.T) {
+	for _, collector := range builtInCollectors(allLabelsConfig()) {
+		for family, labels := range describedFamilies(t, collector) {
+			for _, label := range labels {
+				if !slices.Contains(config.ReservedLabelNames, label) {
+					t.Errorf("label %s of metric family %s is missing from config.ReservedLabelNames", label, family)
+				}
+			}
+		}
+	}
+}
+
+// TestOptionalLabelsUnused makes sure that the inventory and pool labels, which the config only reserves when they are
+// enabled, aren't used by any metric family when they aren't. The pool families always have a pool label, but they are
+// only exported when pools are scraped.
+func TestOptionalLabelsUnused(t *testing.T) {
+	optionalLabels := append(slices.Clone(config.InventoryLabelNames), "pool")
+	for _, collector := range builtInCollectors(&config.Config{}) {
+		for family, labels := range describedFamilies(t, collector) {
+			if strings.HasPrefix(family, "pool_") {
+				continue
+			}
+			for _, label := range labels {
+				if slices.Contains(optionalLabels, label) {
+					t.Errorf("label %s of metric family %s is used without being enabled", label, family)
+				}
+			}
+		}
+	}
+}
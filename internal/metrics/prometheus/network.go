@@ -0,0 +1,63 @@
+package prometheus
+
+import (
+	"strconv"
+
+	"github.com/aauren/ntopng-exporter/internal/config"
+	"github.com/aauren/ntopng-exporter/internal/ntopng"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var networkLabels = []string{"network", "network_id", "ifname"}
+
+type networkCollector struct {
+	ntopNGController *ntopng.Controller
+	config           *config.Config
+	metrics          *metricSet
+	egressBytes      *prometheus.Desc
+	ingressBytes     *prometheus.Desc
+	innerBytes       *prometheus.Desc
+	numHosts         *prometheus.Desc
+	score            *prometheus.Desc
+	throughputBPS    *prometheus.Desc
+}
+
+func NewNtopNGNetworkCollector(ntopController *ntopng.Controller, config *config.Config) *networkCollector {
+	metrics := newMetricSet(ntopController, config)
+	return &networkCollector{
+		ntopNGController: ntopController,
+		config:           config,
+		metrics:          metrics,
+		egressBytes: metrics.newDesc("network", "egress_bytes",
+			"number of bytes sent from local network to other networks", networkLabels),
+		ingressBytes: metrics.newDesc("network", "ingress_bytes",
+			"number of bytes received by local network from other networks", networkLabels),
+		innerBytes: metrics.newDesc("network", "inner_bytes",
+			"number of bytes exchanged between hosts within local network", networkLabels),
+		numHosts: metrics.newDesc("network", "num_hosts",
+			"number of active hosts in local network", networkLabels),
+		score: metrics.newDesc("network", "score",
+			"current ntopng score of local network", networkLabels),
+		throughputBPS: metrics.newDesc("network", "current_throughput_bps",
+			"current throughput of local network in bytes per second", networkLabels),
+	}
+}
+
+func (c *networkCollector) Describe(ch chan<- *prometheus.Desc) {
+	c.metrics.describe(ch)
+}
+
+func (c *networkCollector) Collect(ch chan<- prometheus.Metric) {
+	m := c.metrics.sink(ch)
+	c.ntopNGController.ListRWMutex.RLock()
+	defer c.ntopNGController.ListRWMutex.RUnlock()
+	for _, network := range c.ntopNGController.NetworkList {
+		var networkLabelValues = []string{network.Network, strconv.Itoa(network.NetworkID), network.IfName}
+		m.emit(c.egressBytes, prometheus.CounterValue, network.EgressBytes, networkLabelValues...)
+		m.emit(c.ingressBytes, prometheus.CounterValue, network.IngressBytes, networkLabelValues...)
+		m.emit(c.innerBytes, prometheus.CounterValue, network.InnerBytes, networkLabelValues...)
+		m.emit(c.numHosts, prometheus.GaugeValue, network.NumHosts, networkLabelValues...)
+		m.emit(c.score, prometheus.GaugeValue, network.Score, networkLabelValues...)
+		m.emit(c.throughputBPS, prometheus.GaugeValue, network.ThroughputBPS, networkLabelValues...)
+	}
+}
@@ -0,0 +1,53 @@
+package prometheus
+
+import (
+	"github.com/aauren/ntopng-exporter/internal/config"
+	"github.com/aauren/ntopng-exporter/internal/ntopng"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var osStatsLabels = []string{"os", "ifname"}
+
+type osStatsCollector struct {
+	ntopNGController *ntopng.Controller
+	config           *config.Config
+	metrics          *metricSet
+	bytesRcvd        *prometheus.Desc
+	bytesSent        *prometheus.Desc
+	numHosts         *prometheus.Desc
+	throughputBPS    *prometheus.Desc
+}
+
+func NewNtopNGOSStatsCollector(ntopController *ntopng.Controller, config *config.Config) *osStatsCollector {
+	metrics := newMetricSet(ntopController, config)
+	return &osStatsCollector{
+		ntopNGController: ntopController,
+		config:           config,
+		metrics:          metrics,
+		bytesRcvd: metrics.newDesc("os", "bytes_rcvd",
+			"number of bytes received by hosts running operating system", osStatsLabels),
+		bytesSent: metrics.newDesc("os", "bytes_sent",
+			"number of bytes sent by hosts running operating system", osStatsLabels),
+		numHosts: metrics.newDesc("os", "num_hosts",
+			"number of active hosts running operating system", osStatsLabels),
+		throughputBPS: metrics.newDesc("os", "current_throughput_bps",
+			"current throughput of hosts running operating system in bytes per second", osStatsLabels),
+	}
+}
+
+func (c *osStatsCollector) Describe(ch chan<- *prometheus.Desc) {
+	c.metrics.describe(ch)
+}
+
+func (c *osStatsCollector) Collect(ch chan<- prometheus.Metric) {
+	m := c.metrics.sink(ch)
+	c.ntopNGController.ListRWMutex.RLock()
+	defer c.ntopNGController.ListRWMutex.RUnlock()
+	for _, os := range c.ntopNGController.OSList {
+		var osLabelValues = []string{os.OS, os.IfName}
+		m.emit(c.bytesRcvd, prometheus.CounterValue, os.BytesReceived, osLabelValues...)
+		m.emit(c.bytesSent, prometheus.CounterValue, os.BytesSent, osLabelValues...)
+		m.emit(c.numHosts, prometheus.GaugeValue, os.NumHosts, osLabelValues...)
+		m.emit(c.throughputBPS, prometheus.GaugeValue, os.ThroughputBPS, osLabelValues...)
+	}
+}
@@ -0,0 +1,72 @@
+package prometheus
+
+import (
+	"strconv"
+
+	"github.com/aauren/ntopng-exporter/internal/config"
+	"github.com/aauren/ntopng-exporter/internal/ntopng"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	poolLabels      = []string{"pool_id", "pool"}
+	poolStatsLabels = deepAppend(poolLabels, "ifname")
+)
+
+type poolCollector struct {
+	ntopNGController *ntopng.Controller
+	config           *config.Config
+	metrics          *metricSet
+	bytesRcvd        *prometheus.Desc
+	bytesSent        *prometheus.Desc
+	members          *prometheus.Desc
+	numHosts         *prometheus.Desc
+	packetsRcvd      *prometheus.Desc
+	packetsSent      *prometheus.Desc
+	throughputBPS    *prometheus.Desc
+}
+
+func NewNtopNGPoolCollector(ntopController *ntopng.Controller, config *config.Config) *poolCollector {
+	metrics := newMetricSet(ntopController, config)
+	return &poolCollector{
+		ntopNGController: ntopController,
+		config:           config,
+		metrics:          metrics,
+		bytesRcvd: metrics.newDesc("pool", "bytes_rcvd",
+			"number of bytes received by hosts in host pool", poolStatsLabels),
+		bytesSent: metrics.newDesc("pool", "bytes_sent",
+			"number of bytes sent by hosts in host pool", poolStatsLabels),
+		members: metrics.newDesc("pool", "members",
+			"number of members (addresses, networks or MACs) configured in host pool", poolLabels),
+		numHosts: metrics.newDesc("pool", "num_hosts",
+			"number of active hosts in host pool", poolStatsLabels),
+		packetsRcvd: metrics.newDesc("pool", "packets_rcvd",
+			"number of packets received by hosts in host pool", poolStatsLabels),
+		packetsSent: metrics.newDesc("pool", "packets_sent",
+			"number of packets sent by hosts in host pool", poolStatsLabels),
+		throughputBPS: metrics.newDesc("pool", "current_throughput_bps",
+			"current throughput of host pool in bytes per second", poolStatsLabels),
+	}
+}
+
+func (c *poolCollector) Describe(ch chan<- *prometheus.Desc) {
+	c.metrics.describe(ch)
+}
+
+func (c *poolCollector) Collect(ch chan<- prometheus.Metric) {
+	m := c.metrics.sink(ch)
+	c.ntopNGController.ListRWMutex.RLock()
+	defer c.ntopNGController.ListRWMutex.RUnlock()
+	for _, pool := range c.ntopNGController.Pools {
+		m.emit(c.members, prometheus.GaugeValue, float64(len(pool.Members)), strconv.Itoa(pool.PoolID), pool.Name)
+	}
+	for _, pool := range c.ntopNGController.PoolList {
+		var poolLabelValues = []string{strconv.Itoa(pool.PoolID), pool.Name, pool.IfName}
+		m.emit(c.bytesRcvd, prometheus.CounterValue, pool.BytesReceived, poolLabelValues...)
+		m.emit(c.bytesSent, prometheus.CounterValue, pool.BytesSent, poolLabelValues...)
+		m.emit(c.numHosts, prometheus.GaugeValue, pool.NumHosts, poolLabelValues...)
+		m.emit(c.packetsRcvd, prometheus.CounterValue, pool.PacketsReceived, poolLabelValues...)
+		m.emit(c.packetsSent, prometheus.CounterValue, pool.PacketsSent, poolLabelValues...)
+		m.emit(c.throughputBPS, prometheus.GaugeValue, pool.ThroughputBPS, poolLabelValues...)
+	}
+}
@@ -0,0 +1,112 @@
+package prometheus
+
+import (
+	"github.com/aauren/ntopng-exporter/internal/config"
+	"github.com/aauren/ntopng-exporter/internal/ntopng"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	asnLabels     = []string{"asn", "as_org", "ifname"}
+	countryLabels = []string{"country", "ifname"}
+	subnetLabels  = []string{"subnet", "cidr", "ifname"}
+	vlanLabels    = []string{"vlan", "ifname"}
+)
+
+const (
+	// The country and ASN rollups have their own subsystems, as ntopng_country_* is already used by the country stats
+	// that ntopng reports
+	asnRollupSubsystem     = "rollup_asn"
+	countryRollupSubsystem = "rollup_country"
+)
+
+type rollupCollector struct {
+	ntopNGController  *ntopng.Controller
+	config            *config.Config
+	metrics           *metricSet
+	rollupType        string
+	labelValues       func(*ntopng.NtopRollup) []string
+	activeClientFlows *prometheus.Desc
+	activeHosts       *prometheus.Desc
+	activeServerFlows *prometheus.Desc
+	bytesRcvd         *prometheus.Desc
+	bytesSent         *prometheus.Desc
+	packetsRcvd       *prometheus.Desc
+	packetsSent       *prometheus.Desc
+}
+
+func NewNtopNGSubnetCollector(ntopController *ntopng.Controller, config *config.Config) *rollupCollector {
+	return newRollupCollector(ntopController, config, ntopng.SubnetRollup, ntopng.SubnetRollup, subnetLabels,
+		func(rollup *ntopng.NtopRollup) []string {
+			return []string{rollup.Name, rollup.Detail, rollup.IfName}
+		})
+}
+
+func NewNtopNGVLANCollector(ntopController *ntopng.Controller, config *config.Config) *rollupCollector {
+	return newRollupCollector(ntopController, config, ntopng.VLANRollup, ntopng.VLANRollup, vlanLabels,
+		func(rollup *ntopng.NtopRollup) []string {
+			return []string{rollup.Name, rollup.IfName}
+		})
+}
+
+func NewNtopNGCountryCollector(ntopController *ntopng.Controller, config *config.Config) *rollupCollector {
+	return newRollupCollector(ntopController, config, ntopng.CountryRollup, countryRollupSubsystem, countryLabels,
+		func(rollup *ntopng.NtopRollup) []string {
+			return []string{rollup.Name, rollup.IfName}
+		})
+}
+
+func NewNtopNGASNCollector(ntopController *ntopng.Controller, config *config.Config) *rollupCollector {
+	return newRollupCollector(ntopController, config, ntopng.ASNRollup, asnRollupSubsystem, asnLabels,
+		func(rollup *ntopng.NtopRollup) []string {
+			return []string{rollup.Name, rollup.Detail, rollup.IfName}
+		})
+}
+
+func newRollupCollector(ntopController *ntopng.Controller, config *config.Config, rollupType string, subsystem string,
+	labels []string, labelValues func(*ntopng.NtopRollup) []string) *rollupCollector {
+	metrics := newMetricSet(ntopController, config)
+	return &rollupCollector{
+		ntopNGController: ntopController,
+		config:           config,
+		metrics:          metrics,
+		rollupType:       rollupType,
+		labelValues:      labelValues,
+		activeClientFlows: metrics.newDesc(subsystem, "active_client_flows",
+			"current number of active client flows for all hosts in "+rollupType, labels),
+		activeHosts: metrics.newDesc(subsystem, "active_hosts",
+			"current number of active hosts in "+rollupType, labels),
+		activeServerFlows: metrics.newDesc(subsystem, "active_server_flows",
+			"current number of active server flows for all hosts in "+rollupType, labels),
+		bytesRcvd: metrics.newDesc(subsystem, "bytes_rcvd",
+			"number of bytes received by the hosts that are currently active in "+rollupType+", it drops when hosts become inactive", labels),
+		bytesSent: metrics.newDesc(subsystem, "bytes_sent",
+			"number of bytes sent by the hosts that are currently active in "+rollupType+", it drops when hosts become inactive", labels),
+		packetsRcvd: metrics.newDesc(subsystem, "packets_rcvd",
+			"number of packets received by the hosts that are currently active in "+rollupType+", it drops when hosts become inactive", labels),
+		packetsSent: metrics.newDesc(subsystem, "packets_sent",
+			"number of packets sent by the hosts that are currently active in "+rollupType+", it drops when hosts become inactive", labels),
+	}
+}
+
+func (c *rollupCollector) Describe(ch chan<- *prometheus.Desc) {
+	c.metrics.describe(ch)
+}
+
+func (c *rollupCollector) Collect(ch chan<- prometheus.Metric) {
+	sink := c.metrics.sink(ch)
+	c.ntopNGController.ListRWMutex.RLock()
+	defer c.ntopNGController.ListRWMutex.RUnlock()
+	for _, rollup := range c.ntopNGController.Rollups[c.rollupType] {
+		// Rollups are calculated from the hosts, so they were produced when the hosts of the interface were scraped
+		m := sink.at(c.ntopNGController.ScrapeTimes[config.HostScrape][rollup.IfName])
+		rollupLabelValues := c.labelValues(&rollup)
+		m.emit(c.activeClientFlows, prometheus.GaugeValue, rollup.ActiveClientFlows, rollupLabelValues...)
+		m.emit(c.activeHosts, prometheus.GaugeValue, rollup.ActiveHosts, rollupLabelValues...)
+		m.emit(c.activeServerFlows, prometheus.GaugeValue, rollup.ActiveServerFlows, rollupLabelValues...)
+		m.emit(c.bytesRcvd, prometheus.GaugeValue, rollup.BytesReceived, rollupLabelValues...)
+		m.emit(c.bytesSent, prometheus.GaugeValue, rollup.BytesSent, rollupLabelValues...)
+		m.emit(c.packetsRcvd, prometheus.GaugeValue, rollup.PacketsReceived, rollupLabelValues...)
+		m.emit(c.packetsSent, prometheus.GaugeValue, rollup.PacketsSent, rollupLabelValues...)
+	}
+}
@@ -0,0 +1,104 @@
+package prometheus
+
+import (
+	"strconv"
+
+	"github.com/aauren/ntopng-exporter/internal/config"
+	"github.com/aauren/ntopng-exporter/internal/ntopng"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	percentPerRatio = 100
+	snmpStatusUp    = "up"
+)
+
+var (
+	snmpDeviceLabels          = []string{"device_ip", "device_name"}
+	snmpDeviceInfoLabels      = deepAppend(snmpDeviceLabels, "vendor")
+	snmpPortLabels            = deepAppend(snmpDeviceLabels, "port_index", "port_name", "port_alias")
+	snmpPortDirectionalLabels = deepAppend(snmpPortLabels, "direction")
+)
+
+type snmpCollector struct {
+	ntopNGController *ntopng.Controller
+	config           *config.Config
+	metrics          *metricSet
+	deviceInfo       *prometheus.Desc
+	deviceUp         *prometheus.Desc
+	deviceUptime     *prometheus.Desc
+	portAdminUp      *prometheus.Desc
+	portBytesRcvd    *prometheus.Desc
+	portBytesSent    *prometheus.Desc
+	portDiscards     *prometheus.Desc
+	portErrors       *prometheus.Desc
+	portSpeed        *prometheus.Desc
+	portUp           *prometheus.Desc
+	portUtilization  *prometheus.Desc
+}
+
+func NewNtopNGSNMPCollector(ntopController *ntopng.Controller, config *config.Config) *snmpCollector {
+	metrics := newMetricSet(ntopController, config)
+	return &snmpCollector{
+		ntopNGController: ntopController,
+		config:           config,
+		metrics:          metrics,
+		deviceInfo: metrics.newDesc("snmp", "device_info",
+			"metadata about SNMP device polled by ntopng, always has a value of 1", snmpDeviceInfoLabels),
+		deviceUp: metrics.newDesc("snmp", "device_up",
+			"whether SNMP device responded the last time that ntopng polled it", snmpDeviceLabels),
+		deviceUptime: metrics.newDesc("snmp", "device_uptime_seconds",
+			"uptime of SNMP device as reported by the device", snmpDeviceLabels),
+		portAdminUp: metrics.newDesc("snmp", "port_admin_up",
+			"whether SNMP port is administratively up", snmpPortLabels),
+		portBytesRcvd: metrics.newDesc("snmp", "port_bytes_rcvd",
+			"number of octets received on SNMP port", snmpPortLabels),
+		portBytesSent: metrics.newDesc("snmp", "port_bytes_sent",
+			"number of octets sent on SNMP port", snmpPortLabels),
+		portDiscards: metrics.newDesc("snmp", "port_discards",
+			"number of packets discarded on SNMP port by direction", snmpPortDirectionalLabels),
+		portErrors: metrics.newDesc("snmp", "port_errors",
+			"number of packets with errors on SNMP port by direction", snmpPortDirectionalLabels),
+		portSpeed: metrics.newDesc("snmp", "port_speed_bps",
+			"speed of SNMP port in bits per second", snmpPortLabels),
+		portUp: metrics.newDesc("snmp", "port_up",
+			"whether SNMP port is operationally up", snmpPortLabels),
+		portUtilization: metrics.newDesc("snmp", "port_utilization_ratio",
+			"current utilisation of SNMP port as a ratio of its speed by direction", snmpPortDirectionalLabels),
+	}
+}
+
+func (c *snmpCollector) Describe(ch chan<- *prometheus.Desc) {
+	c.metrics.describe(ch)
+}
+
+func (c *snmpCollector) Collect(ch chan<- prometheus.Metric) {
+	m := c.metrics.sink(ch)
+	c.ntopNGController.ListRWMutex.RLock()
+	defer c.ntopNGController.ListRWMutex.RUnlock()
+	for _, device := range c.ntopNGController.SNMPDeviceList {
+		var deviceLabelValues = []string{device.IP, device.Name}
+		m.emit(c.deviceInfo, prometheus.GaugeValue, 1, deepAppend(deviceLabelValues, device.Vendor)...)
+		m.emit(c.deviceUp, prometheus.GaugeValue, boolToFloat(!device.Unreachable), deviceLabelValues...)
+		m.emit(c.deviceUptime, prometheus.GaugeValue, device.Uptime, deviceLabelValues...)
+		for _, port := range device.Ports {
+			portLabelValues := deepAppend(deviceLabelValues, strconv.Itoa(port.Index), port.Name, port.Alias)
+			m.emit(c.portAdminUp, prometheus.GaugeValue,
+				boolToFloat(port.AdminStatus == snmpStatusUp), portLabelValues...)
+			m.emit(c.portBytesRcvd, prometheus.CounterValue, port.BytesReceived, portLabelValues...)
+			m.emit(c.portBytesSent, prometheus.CounterValue, port.BytesSent, portLabelValues...)
+			m.emit(c.portDiscards, prometheus.CounterValue, port.DiscardsReceived,
+				deepAppend(portLabelValues, "received")...)
+			m.emit(c.portDiscards, prometheus.CounterValue, port.DiscardsSent, deepAppend(portLabelValues, "sent")...)
+			m.emit(c.portErrors, prometheus.CounterValue, port.ErrorsReceived,
+				deepAppend(portLabelValues, "received")...)
+			m.emit(c.portErrors, prometheus.CounterValue, port.ErrorsSent, deepAppend(portLabelValues, "sent")...)
+			m.emit(c.portSpeed, prometheus.GaugeValue, port.Speed, portLabelValues...)
+			m.emit(c.portUp, prometheus.GaugeValue, boolToFloat(port.OperStatus == snmpStatusUp), portLabelValues...)
+			m.emit(c.portUtilization, prometheus.GaugeValue,
+				port.UtilizationReceived/percentPerRatio, deepAppend(portLabelValues, "received")...)
+			m.emit(c.portUtilization, prometheus.GaugeValue,
+				port.UtilizationSent/percentPerRatio, deepAppend(portLabelValues, "sent")...)
+		}
+	}
+}
@@ -0,0 +1,121 @@
+package prometheus
+
+import (
+	"github.com/aauren/ntopng-exporter/internal/config"
+	"github.com/aauren/ntopng-exporter/internal/ntopng"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const millisecondsPerSecond = 1000
+
+var (
+	buildInfoLabels      = []string{"version", "revision", "platform", "edition"}
+	licenseLabels        = []string{"type"}
+	periodicScriptLabels = []string{"script"}
+	hashTableLabels      = []string{"table"}
+)
+
+type systemCollector struct {
+	ntopNGController          *ntopng.Controller
+	config                    *config.Config
+	metrics                   *metricSet
+	buildInfo                 *prometheus.Desc
+	clickHouseUp              *prometheus.Desc
+	cpuLoad                   *prometheus.Desc
+	hashTableFill             *prometheus.Desc
+	licenseExpiry             *prometheus.Desc
+	licenseValid              *prometheus.Desc
+	memoryResident            *prometheus.Desc
+	memoryTotal               *prometheus.Desc
+	memoryUsed                *prometheus.Desc
+	periodicScriptDuration    *prometheus.Desc
+	periodicScriptMaxDuration *prometheus.Desc
+	periodicScriptSkipped     *prometheus.Desc
+	redisKeys                 *prometheus.Desc
+	redisMemory               *prometheus.Desc
+	redisUp                   *prometheus.Desc
+	uptime                    *prometheus.Desc
+}
+
+func NewNtopNGSystemCollector(ntopController *ntopng.Controller, config *config.Config) *systemCollector {
+	metrics := newMetricSet(ntopController, config)
+	return &systemCollector{
+		ntopNGController: ntopController,
+		config:           config,
+		metrics:          metrics,
+		buildInfo: metrics.newDesc("", "build_info",
+			"version information about the running ntopng instance, always has a value of 1", buildInfoLabels),
+		clickHouseUp: metrics.newDesc("clickhouse", "up",
+			"whether the ClickHouse database used by ntopng for flow storage is running, only exported when enabled",
+			nil),
+		cpuLoad: metrics.newDesc("system", "cpu_load",
+			"current CPU load of the system that ntopng is running on", nil),
+		hashTableFill: metrics.newDesc("system", "hash_table_fill_ratio",
+			"ratio of used to maximum entries of ntopng hash table across all interfaces", hashTableLabels),
+		licenseExpiry: metrics.newDesc("license", "expiry_timestamp_seconds",
+			"unix timestamp of when the ntopng license expires, only exported for licenses that expire", licenseLabels),
+		licenseValid: metrics.newDesc("license", "valid",
+			"whether ntopng is running with a valid license", licenseLabels),
+		memoryResident: metrics.newDesc("", "resident_memory_bytes",
+			"resident memory used by the ntopng process in bytes", nil),
+		memoryTotal: metrics.newDesc("system", "memory_total_bytes",
+			"total memory of the system that ntopng is running on in bytes", nil),
+		memoryUsed: metrics.newDesc("system", "memory_used_bytes",
+			"used memory of the system that ntopng is running on in bytes", nil),
+		periodicScriptDuration: metrics.newDesc("periodic_script", "last_duration_seconds",
+			"duration of the last run of ntopng periodic script", periodicScriptLabels),
+		periodicScriptMaxDuration: metrics.newDesc("periodic_script", "max_duration_seconds",
+			"maximum duration that ntopng periodic script is allowed to run for", periodicScriptLabels),
+		periodicScriptSkipped: metrics.newDesc("periodic_script", "skipped_total",
+			"number of times that ntopng periodic script was skipped because the previous run had not finished",
+			periodicScriptLabels),
+		redisKeys: metrics.newDesc("redis", "keys",
+			"number of keys in the Redis database used by ntopng", nil),
+		redisMemory: metrics.newDesc("redis", "memory_bytes",
+			"memory used by the Redis database used by ntopng in bytes", nil),
+		redisUp: metrics.newDesc("redis", "up",
+			"whether the Redis database used by ntopng is running", nil),
+		uptime: metrics.newDesc("", "uptime_seconds",
+			"number of seconds since ntopng was started", nil),
+	}
+}
+
+func (c *systemCollector) Describe(ch chan<- *prometheus.Desc) {
+	c.metrics.describe(ch)
+}
+
+func (c *systemCollector) Collect(ch chan<- prometheus.Metric) {
+	m := c.metrics.sink(ch)
+	c.ntopNGController.ListRWMutex.RLock()
+	defer c.ntopNGController.ListRWMutex.RUnlock()
+	if info := c.ntopNGController.SystemInfo; info != nil {
+		m.emit(c.buildInfo, prometheus.GaugeValue, 1, info.Version, info.Revision, info.Platform, info.Edition)
+		m.emit(c.uptime, prometheus.GaugeValue, info.Uptime)
+		m.emit(c.licenseValid, prometheus.GaugeValue, boolToFloat(info.License.Valid), info.License.Type)
+		if info.License.Expiry > 0 {
+			m.emit(c.licenseExpiry, prometheus.GaugeValue, info.License.Expiry, info.License.Type)
+		}
+	}
+	if health := c.ntopNGController.SystemHealth; health != nil {
+		m.emit(c.cpuLoad, prometheus.GaugeValue, health.CPULoad)
+		m.emit(c.memoryResident, prometheus.GaugeValue, health.MemoryResident)
+		m.emit(c.memoryTotal, prometheus.GaugeValue, health.MemoryTotal)
+		m.emit(c.memoryUsed, prometheus.GaugeValue, health.MemoryUsed)
+		m.emit(c.redisKeys, prometheus.GaugeValue, health.Redis.Keys)
+		m.emit(c.redisMemory, prometheus.GaugeValue, health.Redis.Memory)
+		m.emit(c.redisUp, prometheus.GaugeValue, boolToFloat(health.Redis.Running))
+		if health.ClickHouse.Enabled {
+			m.emit(c.clickHouseUp, prometheus.GaugeValue, boolToFloat(health.ClickHouse.Running))
+		}
+		for script, stats := range health.PeriodicScripts {
+			m.emit(c.periodicScriptDuration, prometheus.GaugeValue, stats.LastDurationMS/millisecondsPerSecond, script)
+			m.emit(c.periodicScriptMaxDuration, prometheus.GaugeValue, stats.MaxDuration, script)
+			m.emit(c.periodicScriptSkipped, prometheus.CounterValue, stats.NumSkipped, script)
+		}
+		for table, usage := range health.HashTables {
+			if usage.Max > 0 {
+				m.emit(c.hashTableFill, prometheus.GaugeValue, usage.Entries/usage.Max, table)
+			}
+		}
+	}
+}
@@ -6,3 +6,11 @@ func deepAppend(src []string, appends ...string) []string {
 	newList = append(newList, appends...)
 	return newList
 }
+
+// boolToFloat converts a boolean into a metric value of 1 or 0
+func boolToFloat(b bool) float64 {
+	if b {
+		return 1
+	}
+	return 0
+}
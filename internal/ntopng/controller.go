@@ -6,40 +6,125 @@ import (
 	"crypto/tls"
 	"encoding/json"
 	"fmt"
-	"net"
 	"net/http"
+	"net/url"
 	"strconv"
+	"strings"
 	"sync"
 	"time"
 
-	"github.com/aauren/ntopng-exporter/internal"
 	"github.com/aauren/ntopng-exporter/internal/config"
+	"github.com/aauren/ntopng-exporter/internal/enrichment"
 )
 
 const (
 	luaRestV2Get     = "/lua/rest/v2/get"
-	hostCustomFields = `ip,bytes.sent,bytes.rcvd,active_flows.as_client,active_flows.as_server,dns,` +
-		`num_alerts,mac,total_flows.as_client,total_flows.as_server,vlan,total_alerts,name,ifid,` +
-		`packets.rcvd,packets.sent`
-	hostCustomPath    = "/host/custom_data.lua"
-	interfaceListPath = "/ntopng/interfaces.lua"
-	interfaceDataPath = "/interface/data.lua"
+	luaProRestV2Get  = "/lua/pro/rest/v2/get"
+	hostCustomFields = `ip,bytes.sent,bytes.rcvd,active_flows.as_client,active_flows.as_server,` +
+		`mac,total_flows.as_client,total_flows.as_server,vlan,total_alerts,name,ifid,` +
+		`packets.rcvd,packets.sent,host_pool_id`
+	hostAlertFields      = `num_alerts`
+	hostDNSFields        = `dns`
+	hostScoreFields      = `score.as_client,score.as_server`
+	hostThroughputFields = `throughput_bps,throughput_pps`
+	hostTCPFields        = `tcpPacketStats.sent,tcpPacketStats.rcvd`
+	hostFlowHealthFields = `unreachable_flows.as_client,unreachable_flows.as_server,misbehaving_flows.as_client,` +
+		`misbehaving_flows.as_server`
+	hostTimestampFields = `seen.first,seen.last,duration`
+	hostFlagFields      = `is_blacklisted,is_localhost`
+	hostOSFields        = `os_detail`
+	hostCustomPath      = "/host/custom_data.lua"
+	interfaceListPath   = "/ntopng/interfaces.lua"
+	interfaceDataPath   = "/interface/data.lua"
+	hashTablesPath      = "/interface/hash_tables.lua"
+	asListPath          = "/as/list.lua"
+	countryListPath     = "/country/list.lua"
+	osListPath          = "/os/list.lua"
+	macListPath         = "/mac/list.lua"
+	flowDeviceStatsPath = "/flowdevices/stats.lua"
+	snmpDeviceListPath  = "/snmp/device/list.lua"
+	snmpInterfacesPath  = "/snmp/device/interfaces.lua"
+	networkListPath     = "/network/list.lua"
+	poolListPath        = "/host/pools.lua"
+	poolStatsPath       = "/host/pool/stats.lua"
+	systemInfoPath      = "/ntopng/info.lua"
+	systemHealthPath    = "/system/health/stats.lua"
 )
 
 type Controller struct {
 	config        *config.Config
 	ifList        map[string]int
-	HostList      map[string]ntopHost
-	InterfaceList map[string]ntopInterfaceFull
-	ListRWMutex   *sync.RWMutex
-	stopChan      <-chan struct{}
+	hostFilter    *hostFilter
+	rollupSubnets []rollupSubnet
+	inventory     *enrichment.Inventory
+	ouiDB         *enrichment.OUIDatabase
+	geoIP         *enrichment.GeoIP
+	reverseDNS    *enrichment.ReverseDNS
+	// poolNames maps host pool IDs to their names, it is only used from the scrape loop so it doesn't need locking
+	poolNames map[int]string
+	// otherHosts keeps the counters of the aggregate host of each interface when hosts are limited, it is only used
+	// from the scrape loop as well
+	otherHosts     map[string]*otherHostTotals
+	HostList       map[string]NtopHost
+	InterfaceList  map[string]ntopInterfaceFull
+	ASList         map[string]ntopAS
+	CountryList    map[string]ntopCountry
+	OSList         map[string]ntopOS
+	MACList        map[string]NtopMAC
+	FlowDeviceList map[string]ntopFlowDevice
+	SNMPDeviceList map[string]ntopSNMPDevice
+	NetworkList    map[string]ntopNetwork
+	Pools          map[int]ntopPool
+	PoolList       map[string]ntopPoolStats
+	// SystemInfo and SystemHealth are nil until they have been scraped successfully
+	SystemInfo   *ntopSystemInfo
+	SystemHealth *ntopSystemHealth
+	// CustomSamples holds every value extracted from the custom endpoints during the last scrape
+	CustomSamples []CustomSample
+	// HostsFiltered is a running count of the hosts that have been filtered out of scrapes, keyed by filter rule name
+	HostsFiltered map[string]float64
+	// MACsFiltered is a running count of the MAC devices that have been filtered out of scrapes, keyed by filter rule
+	// name
+	MACsFiltered map[string]float64
+	// Rollups holds the aggregates of the host list keyed first by rollup type (e.g. SubnetRollup) and then by segment
+	Rollups map[string]map[string]NtopRollup
+	// ScrapeTimes holds when each monitored interface was scraped, keyed first by scrape target (e.g. config.HostScrape)
+	// and then by interface name
+	ScrapeTimes map[string]map[string]time.Time
+	// Counters keeps the totals of the exported counters when metric.monotonicCounters is enabled, it is nil otherwise
+	Counters *CounterTracker
+	// InvalidMetrics counts the metrics that collectors failed to build from the scraped data
+	InvalidMetrics *InvalidMetricCounter
+	ListRWMutex    *sync.RWMutex
+	stopChan       <-chan struct{}
+}
+
+// hostScrape holds everything that is gathered during a single scrape of the host endpoint across all interfaces
+type hostScrape struct {
+	hosts map[string]NtopHost
+	// seen holds the IP of every host that ntopng returned, including those that were filtered or limited out
+	seen        map[string]bool
+	filtered    map[string]float64
+	rollups     map[string]map[string]NtopRollup
+	scrapeTimes map[string]time.Time
 }
 
 func CreateController(config *config.Config, stopChan <-chan struct{}) Controller {
 	var controller Controller
 	controller.config = config
 	controller.stopChan = stopChan
+	controller.hostFilter = newHostFilter(config)
+	controller.rollupSubnets = newRollupSubnets(config)
+	controller.HostsFiltered = make(map[string]float64)
+	controller.MACsFiltered = make(map[string]float64)
+	controller.ScrapeTimes = make(map[string]map[string]time.Time)
+	controller.InvalidMetrics = NewInvalidMetricCounter()
 	controller.ListRWMutex = &sync.RWMutex{}
+	if config.Metric.MonotonicCounters.Enabled {
+		// The expiry has already been validated along with the rest of the config
+		expiry, _ := time.ParseDuration(config.Metric.MonotonicCounters.Expiry)
+		controller.Counters = NewCounterTracker(expiry)
+	}
 	return controller
 }
 
@@ -62,14 +147,47 @@ func (c *Controller) RunController() {
 }
 
 func (c *Controller) ScrapeAllConfiguredTargets() {
-	if internal.IsItemInArray(c.config.Ntopng.ScrapeTargets, config.HostScrape) ||
-		internal.IsItemInArray(c.config.Ntopng.ScrapeTargets, config.AllScrape) {
+	c.reloadEnrichment()
+	// Pool definitions are scraped first, as they are needed to name the pool of each host
+	if c.config.IsScrapeTargetEnabled(config.PoolScrape) || c.config.Metric.HostPoolLabel {
+		c.ScrapePoolDefinitions()
+	}
+	if c.config.IsScrapeTargetEnabled(config.HostScrape) {
 		c.ScrapeHostEndpointForAllInterfaces()
 	}
-	if internal.IsItemInArray(c.config.Ntopng.ScrapeTargets, config.InterfaceScrape) ||
-		internal.IsItemInArray(c.config.Ntopng.ScrapeTargets, config.AllScrape) {
+	if c.config.IsScrapeTargetEnabled(config.InterfaceScrape) {
 		c.ScrapeInterfaceEndpointForAllInterfaces()
 	}
+	if c.config.IsScrapeTargetEnabled(config.ASScrape) {
+		c.ScrapeASEndpointForAllInterfaces()
+	}
+	if c.config.IsScrapeTargetEnabled(config.CountryScrape) {
+		c.ScrapeCountryEndpointForAllInterfaces()
+	}
+	if c.config.IsScrapeTargetEnabled(config.OSScrape) {
+		c.ScrapeOSEndpointForAllInterfaces()
+	}
+	if c.config.IsScrapeTargetEnabled(config.MACScrape) {
+		c.ScrapeMACEndpointForAllInterfaces()
+	}
+	if c.config.IsScrapeTargetEnabled(config.PoolScrape) {
+		c.ScrapePoolEndpointForAllInterfaces()
+	}
+	if c.config.IsScrapeTargetEnabled(config.NetworkScrape) {
+		c.ScrapeNetworkEndpointForAllInterfaces()
+	}
+	if c.config.IsScrapeTargetEnabled(config.SystemScrape) {
+		c.ScrapeSystemEndpoints()
+	}
+	if c.config.IsScrapeTargetEnabled(config.FlowDeviceScrape) {
+		c.ScrapeFlowDeviceEndpointForAllInterfaces()
+	}
+	if c.config.IsScrapeTargetEnabled(config.SNMPScrape) {
+		c.ScrapeSNMPEndpoints()
+	}
+	if c.config.IsScrapeTargetEnabled(config.CustomScrape) {
+		c.ScrapeCustomEndpoints()
+	}
 }
 
 func (c *Controller) CacheInterfaceIds() error {
@@ -121,20 +239,53 @@ func (c *Controller) CacheInterfaceIds() error {
 func (c *Controller) ScrapeHostEndpointForAllInterfaces() {
 	// tempNtopHosts is made here to minimize the amount of time we have to lock the list and also to make sure that we
 	// don't keep a list of ever growing hosts in our map which could eventually overwhelm the system
-	tempNtopHosts := make(map[string]ntopHost)
+	tempNtopHosts := hostScrape{
+		hosts:       make(map[string]NtopHost),
+		seen:        make(map[string]bool),
+		filtered:    make(map[string]float64),
+		rollups:     make(map[string]map[string]NtopRollup),
+		scrapeTimes: make(map[string]time.Time),
+	}
 	for _, configuredIf := range c.config.Host.InterfacesToMonitor {
-		if err := c.scrapeHostEndpoint(c.ifList[configuredIf], tempNtopHosts); err != nil {
+		scrapeTime := time.Now()
+		if err := c.scrapeHostEndpoint(c.ifList[configuredIf], scrapeTime, &tempNtopHosts); err != nil {
 			fmt.Printf("failed to scrape interface '%s' with error: %v", configuredIf, err)
+			continue
 		}
+		tempNtopHosts.scrapeTimes[configuredIf] = scrapeTime
+	}
+	if c.config.Metric.HostRetention > 0 {
+		c.retainMissingHosts(&tempNtopHosts)
 	}
 	c.ListRWMutex.Lock()
 	defer c.ListRWMutex.Unlock()
-	c.HostList = tempNtopHosts
+	c.HostList = tempNtopHosts.hosts
+	c.Rollups = tempNtopHosts.rollups
+	c.ScrapeTimes[config.HostScrape] = tempNtopHosts.scrapeTimes
+	for rule, count := range tempNtopHosts.filtered {
+		c.HostsFiltered[rule] += count
+	}
+}
+
+// retainMissingHosts carries over the hosts of the previous scrape that ntopng didn't return this time, until they have
+// been missing for more than the configured number of scrapes. Hosts that ntopng did return, but that were filtered or
+// limited out, are not carried over. Rollups have already been calculated by now, so retained hosts aren't counted in
+// them, which keeps the rollups to the hosts that are currently active in ntopng.
+func (c *Controller) retainMissingHosts(tempNtopHosts *hostScrape) {
+	for key, host := range c.HostList {
+		if host.IP == config.OtherHostIP || tempNtopHosts.seen[host.IP] {
+			continue
+		}
+		host.missedScrapes++
+		if host.missedScrapes <= c.config.Metric.HostRetention {
+			tempNtopHosts.hosts[key] = host
+		}
+	}
 }
 
-func (c *Controller) scrapeHostEndpoint(interfaceId int, tempNtopHosts map[string]ntopHost) error {
+func (c *Controller) scrapeHostEndpoint(interfaceId int, scrapeTime time.Time, tempNtopHosts *hostScrape) error {
 	endpoint := fmt.Sprintf("%s%s%s", c.config.Ntopng.EndPoint, luaRestV2Get, hostCustomPath)
-	payload := []byte(fmt.Sprintf(`{"ifid": %d, "field_alias": "%s"}`, interfaceId, hostCustomFields))
+	payload := []byte(fmt.Sprintf(`{"ifid": %d, "field_alias": "%s"}`, interfaceId, c.hostFields()))
 	req, err := http.NewRequestWithContext(context.Background(), "POST", endpoint, bytes.NewBuffer(payload))
 	if err != nil {
 		return err
@@ -156,59 +307,119 @@ func (c *Controller) scrapeHostEndpoint(interfaceId int, tempNtopHosts map[strin
 	if err != nil {
 		return err
 	}
-	var hostList []ntopHost
+	var hostList []NtopHost
 	_ = json.Unmarshal(rawHosts, &hostList)
 	if len(hostList) < 1 {
 		return fmt.Errorf("ntopng returned 0 hosts: %v", *body)
 	}
-	var parsedSubnets []*net.IPNet
-	if len(c.config.Metric.LocalSubnetsOnly) > 0 {
-		for _, subnet := range c.config.Metric.LocalSubnetsOnly {
-			_, parsedSubnet, _ := net.ParseCIDR(subnet)
-			parsedSubnets = append(parsedSubnets, parsedSubnet)
+	if len(c.config.Metric.CustomHostFields) > 0 {
+		// Custom fields aren't known ahead of time, so they are read from a generic decoding of the same response
+		var rawHostList []map[string]any
+		_ = json.Unmarshal(rawHosts, &rawHostList)
+		for i := range rawHostList {
+			hostList[i].Custom = c.customHostValues(rawHostList[i])
 		}
 	}
+	keptHosts := make([]NtopHost, 0, len(hostList))
 	for _, myHost := range hostList {
+		tempNtopHosts.seen[myHost.IP] = true
 		// If we already have this host in our cache and it has a different ifid than we are currently processing, don't
 		// overwrite it, and print a warning.
 		if err = c.checkForDuplicateInterfaces(&myHost); err != nil {
 			fmt.Println(err)
 			continue
 		}
-		if len(parsedSubnets) > 0 {
-			validIP := false
-			parsedIP := net.ParseIP(myHost.IP)
-			for _, parsedSubnet := range parsedSubnets {
-				if parsedSubnet.Contains(parsedIP) {
-					validIP = true
-					break
-				}
-			}
-			if !validIP {
-				continue
-			}
-		}
 		if myHost.IfName, err = c.ResolveIfID(myHost.IfID); err != nil {
 			fmt.Printf("Could not resolve interface: %d, this should not happen", myHost.IfID)
 			myHost.IfName = strconv.Itoa(myHost.IfID)
 		}
-		tempNtopHosts[myHost.IP] = myHost
+		// Hosts are enriched before they are filtered, so that name filters also see reverse DNS and inventory names
+		c.enrichHost(&myHost)
+		if keep, rule := c.hostFilter.evaluate(myHost.filterSubject()); !keep {
+			tempNtopHosts.filtered[rule]++
+			continue
+		}
+		keptHosts = append(keptHosts, myHost)
+	}
+	// Rollups are calculated before the host limit is applied so that they aren't affected by it
+	c.rollupHosts(keptHosts, tempNtopHosts.rollups)
+	keptHosts, otherHost := c.limitHosts(keptHosts)
+	for _, myHost := range keptHosts {
+		myHost.LastScraped = scrapeTime
+		if c.Counters != nil {
+			c.trackHostCounters(&myHost)
+		}
+		tempNtopHosts.hosts[myHost.IP] = myHost
+	}
+	if otherHost != nil {
+		otherHost.LastScraped = scrapeTime
+		// Keyed by interface as well, so that the aggregates of multiple interfaces don't overwrite each other
+		tempNtopHosts.hosts[fmt.Sprintf("%s/%s", otherHost.IP, otherHost.IfName)] = *otherHost
 	}
 	return nil
 }
 
+// customHostValues extracts the values of all of the configured custom host fields from a single host
+func (c *Controller) customHostValues(rawHost map[string]any) []CustomHostValue {
+	var values []CustomHostValue
+	for i, customField := range c.config.Metric.CustomHostFields {
+		for _, match := range lookupJSONPath(rawHost[customField.Field], customField.Path) {
+			if value, ok := jsonValueToFloat(match.value); ok {
+				values = append(values, CustomHostValue{Field: i, Keys: match.keys, Value: value})
+			}
+		}
+	}
+	return values
+}
+
+// hostFields returns the list of fields to request from ntopng for each host, leaving out the groups of fields that are
+// only used by metric families that have been disabled in the config
+func (c *Controller) hostFields() string {
+	fields := []string{hostCustomFields}
+	metricConfig := &c.config.Metric
+	for _, group := range []struct {
+		families []string
+		fields   string
+	}{
+		{[]string{"host_num_alerts"}, hostAlertFields},
+		{config.DNSMetricFamilies, hostDNSFields},
+		{config.ScoreMetricFamilies, hostScoreFields},
+		{config.ThroughputMetricFamilies, hostThroughputFields},
+		{config.TCPMetricFamilies, hostTCPFields},
+		{config.FlowHealthMetricFamilies, hostFlowHealthFields},
+		{config.TimestampMetricFamilies, hostTimestampFields},
+		{config.FlagMetricFamilies, hostFlagFields},
+		{config.OSMetricFamilies, hostOSFields},
+	} {
+		if metricConfig.AnyMetricEnabled(group.families) {
+			fields = append(fields, group.fields)
+		}
+	}
+	for _, customField := range metricConfig.CustomHostFields {
+		if !metricConfig.IsMetricDisabled("host_" + customField.Name) {
+			fields = append(fields, customField.Field)
+		}
+	}
+	return strings.Join(fields, ",")
+}
+
 func (c *Controller) ScrapeInterfaceEndpointForAllInterfaces() {
 	// tempNtopInterfaces is made here to minimize the amount of time we have to lock the list and also to make sure that we
 	// don't keep a list of ever growing hosts in our map which could eventually overwhelm the system
 	tempNtopInterfaces := make(map[string]ntopInterfaceFull)
+	tempScrapeTimes := make(map[string]time.Time)
 	for _, configuredIf := range c.config.Host.InterfacesToMonitor {
+		scrapeTime := time.Now()
 		if err := c.scrapeInterfaceEndpoint(c.ifList[configuredIf], tempNtopInterfaces); err != nil {
 			fmt.Printf("failed to scrape interface '%s' with error: %v", configuredIf, err)
+			continue
 		}
+		tempScrapeTimes[configuredIf] = scrapeTime
 	}
 	c.ListRWMutex.Lock()
 	defer c.ListRWMutex.Unlock()
 	c.InterfaceList = tempNtopInterfaces
+	c.ScrapeTimes[config.InterfaceScrape] = tempScrapeTimes
 }
 
 func (c *Controller) scrapeInterfaceEndpoint(interfaceId int, tempInterfaces map[string]ntopInterfaceFull) error {
@@ -244,10 +455,247 @@ func (c *Controller) scrapeInterfaceEndpoint(interfaceId int, tempInterfaces map
 			return fmt.Errorf("problem parsing ntop interface: %d - %v", interfaceId, err)
 		}
 	}
+	// Hash table stats are only informational, so failing to get them shouldn't stop the interface from being exported
+	query := url.Values{"ifid": []string{strconv.Itoa(interfaceId)}}
+	if err = c.getNtopResponse(hashTablesPath, query, &ifFull.HashTables); err != nil {
+		fmt.Printf("failed to scrape hash tables for interface '%s' with error: %v\n", ifFull.IfName, err)
+	}
+	if c.Counters != nil {
+		c.trackInterfaceCounters(&ifFull)
+	}
 	tempInterfaces[ifFull.IfName] = ifFull
 	return nil
 }
 
+func (c *Controller) ScrapeASEndpointForAllInterfaces() {
+	tempASList := scrapeListForAllInterfaces(c, asListPath, func(as *ntopAS, ifName string) string {
+		as.IfName = ifName
+		return fmt.Sprintf("%d/%s", as.ASN, ifName)
+	})
+	c.ListRWMutex.Lock()
+	defer c.ListRWMutex.Unlock()
+	c.ASList = tempASList
+}
+
+func (c *Controller) ScrapeCountryEndpointForAllInterfaces() {
+	tempCountryList := scrapeListForAllInterfaces(c, countryListPath, func(country *ntopCountry, ifName string) string {
+		country.IfName = ifName
+		return fmt.Sprintf("%s/%s", country.Country, ifName)
+	})
+	c.ListRWMutex.Lock()
+	defer c.ListRWMutex.Unlock()
+	c.CountryList = tempCountryList
+}
+
+func (c *Controller) ScrapeOSEndpointForAllInterfaces() {
+	tempOSList := scrapeListForAllInterfaces(c, osListPath, func(os *ntopOS, ifName string) string {
+		os.IfName = ifName
+		return fmt.Sprintf("%s/%s", os.OS, ifName)
+	})
+	c.ListRWMutex.Lock()
+	defer c.ListRWMutex.Unlock()
+	c.OSList = tempOSList
+}
+
+func (c *Controller) ScrapeFlowDeviceEndpointForAllInterfaces() {
+	tempFlowDeviceList := scrapeListForAllInterfaces(c, flowDeviceStatsPath,
+		func(device *ntopFlowDevice, ifName string) string {
+			device.IfName = ifName
+			// The same exporter can send to more than one probe, so the probe is part of the key
+			return fmt.Sprintf("%s/%s/%s", device.ExporterIP, device.ProbeIP, ifName)
+		})
+	c.ListRWMutex.Lock()
+	defer c.ListRWMutex.Unlock()
+	c.FlowDeviceList = tempFlowDeviceList
+}
+
+// ScrapeSNMPEndpoints gets the SNMP devices that ntopng Pro polls and then the ports of each device. SNMP devices are not
+// tied to a monitored interface, so this is only done once per scrape.
+func (c *Controller) ScrapeSNMPEndpoints() {
+	var deviceList []ntopSNMPDevice
+	if err := c.getNtopProResponse(snmpDeviceListPath, nil, &deviceList); err != nil {
+		fmt.Printf("failed to scrape SNMP devices with error: %v\n", err)
+		deviceList = nil
+	}
+	tempSNMPDevices := make(map[string]ntopSNMPDevice, len(deviceList))
+	for _, device := range deviceList {
+		query := url.Values{"host": []string{device.IP}}
+		if err := c.getNtopProResponse(snmpInterfacesPath, query, &device.Ports); err != nil {
+			fmt.Printf("failed to scrape SNMP ports for device '%s' with error: %v\n", device.IP, err)
+		}
+		tempSNMPDevices[device.IP] = device
+	}
+	c.ListRWMutex.Lock()
+	defer c.ListRWMutex.Unlock()
+	c.SNMPDeviceList = tempSNMPDevices
+}
+
+// ScrapeCustomEndpoints requests each of the custom endpoints defined in the config and extracts their metrics. Endpoints
+// that reference the ifid are requested once for each monitored interface.
+func (c *Controller) ScrapeCustomEndpoints() {
+	var tempSamples []CustomSample
+	for i := range c.config.Metric.CustomEndpoints {
+		if !c.config.Metric.CustomEndpoints[i].PerInterface() {
+			tempSamples = append(tempSamples, c.scrapeCustomEndpoint(i, "")...)
+			continue
+		}
+		for _, configuredIf := range c.config.Host.InterfacesToMonitor {
+			tempSamples = append(tempSamples, c.scrapeCustomEndpoint(i, configuredIf)...)
+		}
+	}
+	c.ListRWMutex.Lock()
+	defer c.ListRWMutex.Unlock()
+	c.CustomSamples = tempSamples
+}
+
+func (c *Controller) scrapeCustomEndpoint(index int, ifName string) []CustomSample {
+	endpoint := &c.config.Metric.CustomEndpoints[index]
+	ifID := strconv.Itoa(c.ifList[ifName])
+	path := strings.ReplaceAll(endpoint.Path, config.IfIDTemplate, ifID)
+	query := make(url.Values, len(endpoint.Query))
+	for key, value := range endpoint.Query {
+		query.Set(key, strings.ReplaceAll(value, config.IfIDTemplate, ifID))
+	}
+	var rsp any
+	if err := c.getNtopResponse(path, query, &rsp); err != nil {
+		fmt.Printf("failed to scrape custom endpoint '%s' with error: %v\n", endpoint.Name, err)
+		return nil
+	}
+	var samples []CustomSample
+	for i := range endpoint.Metrics {
+		metric := &endpoint.Metrics[i]
+		labelNames := metric.LabelNames()
+		// Items that end up with the same label values as an earlier item would make the whole scrape fail, so only the
+		// first of them is kept
+		seen := make(map[string]bool)
+		for _, item := range lookupJSONPath(rsp, metric.Path) {
+			values := lookupJSONPath(item.value, metric.Value)
+			if len(values) != 1 {
+				continue
+			}
+			value, ok := jsonValueToFloat(values[0].value)
+			if !ok {
+				continue
+			}
+			labelValues := make([]string, 0, len(labelNames)+1)
+			for k, key := range item.keys {
+				if metric.KeyLabels[k] != "" {
+					labelValues = append(labelValues, key)
+				}
+			}
+			for _, label := range labelNames[len(labelValues):] {
+				labelValues = append(labelValues, "")
+				if matches := lookupJSONPath(item.value, metric.Labels[label]); len(matches) == 1 {
+					labelValues[len(labelValues)-1] = jsonValueToString(matches[0].value)
+				}
+			}
+			if endpoint.PerInterface() {
+				labelValues = append(labelValues, ifName)
+			}
+			if key := strings.Join(labelValues, "\x00"); !seen[key] {
+				seen[key] = true
+				samples = append(samples, CustomSample{Endpoint: index, Metric: i, LabelValues: labelValues, Value: value})
+			}
+		}
+	}
+	return samples
+}
+
+func (c *Controller) ScrapeSystemEndpoints() {
+	// Failed scrapes reset the stored values so that we don't keep exporting stale system state
+	var systemInfo, systemHealth = &ntopSystemInfo{}, &ntopSystemHealth{}
+	if err := c.getNtopResponse(systemInfoPath, nil, systemInfo); err != nil {
+		fmt.Printf("failed to scrape ntopng system info with error: %v\n", err)
+		systemInfo = nil
+	}
+	if err := c.getNtopResponse(systemHealthPath, nil, systemHealth); err != nil {
+		fmt.Printf("failed to scrape ntopng system health with error: %v\n", err)
+		systemHealth = nil
+	}
+	c.ListRWMutex.Lock()
+	defer c.ListRWMutex.Unlock()
+	c.SystemInfo = systemInfo
+	c.SystemHealth = systemHealth
+}
+
+func (c *Controller) ScrapeNetworkEndpointForAllInterfaces() {
+	tempNetworkList := scrapeListForAllInterfaces(c, networkListPath, func(network *ntopNetwork, ifName string) string {
+		network.IfName = ifName
+		return fmt.Sprintf("%s/%s", network.Network, ifName)
+	})
+	c.ListRWMutex.Lock()
+	defer c.ListRWMutex.Unlock()
+	c.NetworkList = tempNetworkList
+}
+
+func (c *Controller) ScrapeMACEndpointForAllInterfaces() {
+	tempMACList := make(map[string]NtopMAC)
+	filtered := make(map[string]float64)
+	for _, configuredIf := range c.config.Host.InterfacesToMonitor {
+		var macList []NtopMAC
+		query := url.Values{"ifid": []string{strconv.Itoa(c.ifList[configuredIf])}}
+		if err := c.getNtopResponse(macListPath, query, &macList); err != nil {
+			fmt.Printf("failed to scrape MACs for interface '%s' with error: %v\n", configuredIf, err)
+			continue
+		}
+		for _, myMAC := range macList {
+			myMAC.IfName = configuredIf
+			// MAC devices don't have an IP address, so any filter rules that match on subnets are skipped for them
+			if keep, rule := c.hostFilter.evaluate(myMAC.filterSubject()); !keep {
+				filtered[rule]++
+				continue
+			}
+			if myMAC.Manufacturer == "" && c.ouiDB != nil {
+				myMAC.Manufacturer = c.ouiDB.Lookup(myMAC.MAC)
+			}
+			tempMACList[fmt.Sprintf("%s/%s", myMAC.MAC, configuredIf)] = myMAC
+		}
+	}
+	c.ListRWMutex.Lock()
+	defer c.ListRWMutex.Unlock()
+	c.MACList = tempMACList
+	for rule, count := range filtered {
+		c.MACsFiltered[rule] += count
+	}
+}
+
+func (c *Controller) ScrapePoolDefinitions() {
+	var poolList []ntopPool
+	if err := c.getNtopResponse(poolListPath, nil, &poolList); err != nil {
+		// Keep the previous definitions, pools change rarely and hosts would otherwise lose their pool names
+		fmt.Printf("failed to scrape host pool definitions with error: %v\n", err)
+		return
+	}
+	tempPools := make(map[int]ntopPool, len(poolList))
+	c.poolNames = make(map[int]string, len(poolList))
+	for _, pool := range poolList {
+		tempPools[pool.PoolID] = pool
+		c.poolNames[pool.PoolID] = pool.Name
+	}
+	c.ListRWMutex.Lock()
+	defer c.ListRWMutex.Unlock()
+	c.Pools = tempPools
+}
+
+func (c *Controller) ScrapePoolEndpointForAllInterfaces() {
+	tempPoolList := scrapeListForAllInterfaces(c, poolStatsPath, func(pool *ntopPoolStats, ifName string) string {
+		pool.IfName = ifName
+		pool.Name = c.poolName(pool.PoolID)
+		return fmt.Sprintf("%d/%s", pool.PoolID, ifName)
+	})
+	c.ListRWMutex.Lock()
+	defer c.ListRWMutex.Unlock()
+	c.PoolList = tempPoolList
+}
+
+// poolName returns the name of the host pool, or its ID if the pool definitions don't contain it
+func (c *Controller) poolName(poolID int) string {
+	if name, ok := c.poolNames[poolID]; ok {
+		return name
+	}
+	return strconv.Itoa(poolID)
+}
+
 func (c *Controller) setCommonOptions(req *http.Request, isJsonRequest bool) {
 	if isJsonRequest {
 		req.Header.Add("Content-Type", "application/json")
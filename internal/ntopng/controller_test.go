@@ -0,0 +1,95 @@
+package ntopng
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/aauren/ntopng-exporter/internal/config"
+	"github.com/spf13/viper"
+)
+
+// unmarshalConfigKey sets key to value and unmarshals it into target the same way that the config file is, as the
+// types of most config sections aren't exported
+func unmarshalConfigKey(t *testing.T, key string, value any, target any) {
+	t.Helper()
+	v := viper.New()
+	v.Set(key, value)
+	if err := v.UnmarshalKey(key, target); err != nil {
+		t.Fatalf("was not able to unmarshal %s: %v", key, err)
+	}
+}
+
+func TestScrapeCustomEndpoint(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != luaRestV2Get+"/alert/type/counters.lua" || r.URL.Query().Get("ifid") != "2" ||
+			r.URL.Query().Get("status") != "engaged" {
+			http.NotFound(w, r)
+			return
+		}
+		_, _ = w.Write([]byte(`{"rc_str": "OK", "rc": 0, "rsp": [
+			{"label": "Flow Flood", "count": 3, "severity": {"name": "error"}},
+			{"label": "Blacklisted", "count": "5", "severity": {}},
+			{"label": "Unknown", "count": "n/a", "severity": {"name": "info"}},
+			{"label": "Flow Flood", "count": 7, "severity": {"name": "error"}},
+			{"label": "Scan", "count": true}
+		]}`))
+	}))
+	defer server.Close()
+
+	c := &Controller{config: &config.Config{}, ifList: map[string]int{"eth0": 2}}
+	c.config.Ntopng.EndPoint = server.URL
+	unmarshalConfigKey(t, "customEndpoints", []map[string]any{{
+		"name":  "alert",
+		"path":  "/alert/type/counters.lua",
+		"query": map[string]string{"ifid": config.IfIDTemplate, "status": "engaged"},
+		"metrics": []map[string]any{{
+			"name":      "engaged",
+			"path":      "*",
+			"keyLabels": []string{""},
+			"value":     "count",
+			"labels":    map[string]string{"alert_type": "label", "severity": "severity.name"},
+		}},
+	}}, &c.config.Metric.CustomEndpoints)
+
+	// The non-numeric count is skipped, the second Flow Flood item has the same label values as the first and is
+	// dropped, and items without a label value get an empty one
+	want := []CustomSample{
+		{LabelValues: []string{"Flow Flood", "error", "eth0"}, Value: 3},
+		{LabelValues: []string{"Blacklisted", "", "eth0"}, Value: 5},
+		{LabelValues: []string{"Scan", "", "eth0"}, Value: 1},
+	}
+	if got := c.scrapeCustomEndpoint(0, "eth0"); !reflect.DeepEqual(got, want) {
+		t.Errorf("scrapeCustomEndpoint() = %+v, want %+v", got, want)
+	}
+	c.ifList["eth0"] = 3
+	if got := c.scrapeCustomEndpoint(0, "eth0"); got != nil {
+		t.Errorf("scrapeCustomEndpoint() of a failed request = %+v, want nil", got)
+	}
+}
+
+func TestCustomHostValues(t *testing.T) {
+	c := &Controller{config: &config.Config{}}
+	unmarshalConfigKey(t, "fields", []map[string]any{
+		{"field": "ndpi", "path": "*.bytes.sent", "name": "l7_bytes_sent", "keyLabels": []string{"protocol"}},
+		{"field": "score.as_client", "name": "client_score"},
+		{"field": "missing", "name": "missing"},
+	}, &c.config.Metric.CustomHostFields)
+	rawHost := map[string]any{
+		"ndpi": map[string]any{
+			"TLS":     map[string]any{"bytes.sent": 10.0},
+			"HTTP":    map[string]any{"bytes.sent": "30"},
+			"Unknown": map[string]any{"bytes.sent": nil},
+		},
+		"score.as_client": 5.0,
+	}
+	want := []CustomHostValue{
+		{Field: 0, Keys: []string{"HTTP"}, Value: 30},
+		{Field: 0, Keys: []string{"TLS"}, Value: 10},
+		{Field: 1, Value: 5},
+	}
+	if got := c.customHostValues(rawHost); !reflect.DeepEqual(got, want) {
+		t.Errorf("customHostValues() = %+v, want %+v", got, want)
+	}
+}
@@ -0,0 +1,147 @@
+package ntopng
+
+import (
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// CounterTracker keeps exporter side totals of counters, so that they keep increasing when ntopng restarts, purges and
+// later re-adds a host or resets the statistics of an interface
+type CounterTracker struct {
+	expiry    time.Duration
+	mutex     sync.Mutex
+	series    map[string]*trackedCounter
+	resets    map[string]float64
+	lastSweep time.Time
+}
+
+type trackedCounter struct {
+	last    float64
+	total   float64
+	updated time.Time
+}
+
+func NewCounterTracker(expiry time.Duration) *CounterTracker {
+	return &CounterTracker{
+		expiry:    expiry,
+		series:    make(map[string]*trackedCounter),
+		resets:    make(map[string]float64),
+		lastSweep: time.Now(),
+	}
+}
+
+// Track records the latest value that ntopng reported for the series identified by key, which belongs to metric, and
+// returns the total of the series. A value that is lower than the previous one is counted as a reset of the counter
+// and is added to the total in its entirety.
+func (t *CounterTracker) Track(metric, key string, value float64) float64 {
+	now := time.Now()
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	if now.Sub(t.lastSweep) > t.expiry {
+		t.sweep(now)
+	}
+	counter, exists := t.series[key]
+	if !exists {
+		t.series[key] = &trackedCounter{last: value, total: value, updated: now}
+		return value
+	}
+	if value < counter.last {
+		counter.total += value
+		t.resets[metric]++
+	} else {
+		counter.total += value - counter.last
+	}
+	counter.last = value
+	counter.updated = now
+	return counter.total
+}
+
+// Resets returns the number of counter resets that have been detected, keyed by metric
+func (t *CounterTracker) Resets() map[string]float64 {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	resets := make(map[string]float64, len(t.resets))
+	for metric, count := range t.resets {
+		resets[metric] = count
+	}
+	return resets
+}
+
+// sweep forgets the series that haven't been tracked within the expiry, which keeps hosts that ntopng no longer
+// reports from growing the tracker forever
+func (t *CounterTracker) sweep(now time.Time) {
+	for key, counter := range t.series {
+		if now.Sub(counter.updated) > t.expiry {
+			delete(t.series, key)
+		}
+	}
+	t.lastSweep = now
+}
+
+// trackHostCounters replaces the counters of the host, including its custom counters, with their monotonic totals. It is
+// called once for every host that ntopng returns in a scrape, so the totals don't depend on how often the exporter
+// itself is scraped. Aggregates such as the "other" host and rollups aren't tracked, as their values come and go with
+// the hosts that they are made of.
+func (c *Controller) trackHostCounters(host *NtopHost) {
+	series := "host\x00" + host.IfName + "\x00" + host.IP + "\x00"
+	for i, counter := range host.counters() {
+		*counter.value = c.Counters.Track(c.metricName(counter.family), series+strconv.Itoa(i), *counter.value)
+	}
+	for i := range host.Custom {
+		customValue := &host.Custom[i]
+		customField := &c.config.Metric.CustomHostFields[customValue.Field]
+		if !customField.IsCounter() {
+			continue
+		}
+		key := series + customField.Name + "\x00" + strings.Join(customValue.Keys, "\x00")
+		customValue.Value = c.Counters.Track(c.metricName("host_"+customField.Name), key, customValue.Value)
+	}
+}
+
+// trackInterfaceCounters replaces the counters of the interface with their monotonic totals, it is called once for
+// every scrape of the interface
+func (c *Controller) trackInterfaceCounters(ntopInterface *ntopInterfaceFull) {
+	series := "interface\x00" + ntopInterface.IfName + "\x00"
+	for i, counter := range ntopInterface.counters() {
+		*counter.value = c.Counters.Track(c.metricName(counter.family), series+strconv.Itoa(i), *counter.value)
+	}
+}
+
+// metricName returns the name that a metric family, named without the namespace, is exported as
+func (c *Controller) metricName(family string) string {
+	if c.config.Metric.Namespace == "" {
+		return family
+	}
+	return c.config.Metric.Namespace + "_" + family
+}
+
+// InvalidMetricCounter counts the metrics that collectors failed to build, e.g. because of a duplicate label set, so
+// that bad data from ntopng shows up as a metric rather than as a failed scrape
+type InvalidMetricCounter struct {
+	mutex  sync.Mutex
+	counts map[string]float64
+}
+
+func NewInvalidMetricCounter() *InvalidMetricCounter {
+	return &InvalidMetricCounter{counts: make(map[string]float64)}
+}
+
+// Add counts an invalid metric of the metric family
+func (i *InvalidMetricCounter) Add(metric string) {
+	i.mutex.Lock()
+	defer i.mutex.Unlock()
+	i.counts[metric]++
+}
+
+// Counts returns the number of invalid metrics that have been counted, keyed by metric family
+func (i *InvalidMetricCounter) Counts() map[string]float64 {
+	i.mutex.Lock()
+	defer i.mutex.Unlock()
+	counts := make(map[string]float64, len(i.counts))
+	for metric, count := range i.counts {
+		counts[metric] = count
+	}
+	return counts
+}
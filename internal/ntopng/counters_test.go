@@ -0,0 +1,54 @@
+package ntopng
+
+import (
+	"testing"
+	"time"
+
+	"github.com/aauren/ntopng-exporter/internal/config"
+)
+
+func TestTrackHostCounters(t *testing.T) {
+	c := &Controller{config: &config.Config{}, Counters: NewCounterTracker(time.Hour)}
+	c.config.Metric.Namespace = config.DefaultMetricNamespace
+	unmarshalConfigKey(t, "fields", []map[string]any{{"field": "ndpi", "path": "*.bytes", "name": "l7_bytes",
+		"type": config.CounterMetricType, "keyLabels": []string{"protocol"}}}, &c.config.Metric.CustomHostFields)
+	scrapes := []struct {
+		name          string
+		bytesSent     float64
+		customValue   float64
+		wantBytesSent float64
+		wantCustom    float64
+		wantResets    map[string]float64
+	}{
+		{name: "first scrape starts from ntopng's value", bytesSent: 100, customValue: 10, wantBytesSent: 100,
+			wantCustom: 10, wantResets: map[string]float64{}},
+		{name: "increases are added", bytesSent: 150, customValue: 15, wantBytesSent: 150, wantCustom: 15,
+			wantResets: map[string]float64{}},
+		{name: "reset adds the whole value", bytesSent: 20, customValue: 15, wantBytesSent: 170, wantCustom: 15,
+			wantResets: map[string]float64{"ntopng_host_bytes_sent": 1}},
+		{name: "custom counter reset", bytesSent: 30, customValue: 5, wantBytesSent: 180, wantCustom: 20,
+			wantResets: map[string]float64{"ntopng_host_bytes_sent": 1, "ntopng_host_l7_bytes": 1}},
+	}
+	for _, scrape := range scrapes {
+		t.Run(scrape.name, func(t *testing.T) {
+			host := NtopHost{IP: "10.0.0.1", IfName: "eth0", BytesSent: scrape.bytesSent,
+				Custom: []CustomHostValue{{Field: 0, Keys: []string{"HTTP"}, Value: scrape.customValue}}}
+			c.trackHostCounters(&host)
+			if host.BytesSent != scrape.wantBytesSent {
+				t.Errorf("BytesSent = %v, want %v", host.BytesSent, scrape.wantBytesSent)
+			}
+			if host.Custom[0].Value != scrape.wantCustom {
+				t.Errorf("custom value = %v, want %v", host.Custom[0].Value, scrape.wantCustom)
+			}
+			resets := c.Counters.Resets()
+			if len(resets) != len(scrape.wantResets) {
+				t.Errorf("Resets() = %v, want %v", resets, scrape.wantResets)
+			}
+			for metric, count := range scrape.wantResets {
+				if resets[metric] != count {
+					t.Errorf("Resets()[%s] = %v, want %v", metric, resets[metric], count)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,78 @@
+package ntopng
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/aauren/ntopng-exporter/internal/enrichment"
+)
+
+// LoadEnrichment loads all of the configured enrichment sources and starts any background workers that they need, it
+// must be called before the controller starts scraping
+func (c *Controller) LoadEnrichment() error {
+	if c.config.Enrichment.Inventory.Path != "" {
+		inventory, err := enrichment.NewInventory(c.config.Enrichment.Inventory.Path)
+		if err != nil {
+			return fmt.Errorf("failed to load inventory: %v", err)
+		}
+		c.inventory = inventory
+	}
+	if c.config.Enrichment.OUIDatabase != "" {
+		ouiDB, err := enrichment.NewOUIDatabase(c.config.Enrichment.OUIDatabase)
+		if err != nil {
+			return fmt.Errorf("failed to load OUI database: %v", err)
+		}
+		c.ouiDB = ouiDB
+	}
+	if c.config.Enrichment.GeoIP.Enabled() {
+		geoIP, err := enrichment.NewGeoIP(c.config.Enrichment.GeoIP.CountryDatabase,
+			c.config.Enrichment.GeoIP.CityDatabase, c.config.Enrichment.GeoIP.ASNDatabase)
+		if err != nil {
+			return fmt.Errorf("failed to load GeoIP databases: %v", err)
+		}
+		c.geoIP = geoIP
+	}
+	if rdnsConfig := c.config.Enrichment.ReverseDNS; len(rdnsConfig.Subnets) > 0 {
+		cacheTTL, _ := time.ParseDuration(rdnsConfig.CacheTTL)
+		negativeCacheTTL, _ := time.ParseDuration(rdnsConfig.NegativeCacheTTL)
+		timeout, _ := time.ParseDuration(rdnsConfig.Timeout)
+		reverseDNS, err := enrichment.NewReverseDNS(rdnsConfig.Subnets, rdnsConfig.Server, cacheTTL, negativeCacheTTL,
+			timeout, rdnsConfig.LookupsPerSecond)
+		if err != nil {
+			return fmt.Errorf("failed to setup reverse DNS: %v", err)
+		}
+		c.reverseDNS = reverseDNS
+		go c.reverseDNS.Run(c.stopChan)
+	}
+	return nil
+}
+
+// reloadEnrichment picks up any changes that have been made on disk to enrichment sources since they were last loaded
+func (c *Controller) reloadEnrichment() {
+	if c.inventory != nil {
+		if reloaded, err := c.inventory.ReloadIfChanged(); err != nil {
+			fmt.Printf("failed to reload inventory, continuing to use previous version: %v\n", err)
+		} else if reloaded {
+			fmt.Printf("inventory file changed on disk and was reloaded\n")
+		}
+	}
+}
+
+func (c *Controller) enrichHost(myHost *NtopHost) {
+	myHost.Pool = c.poolName(myHost.PoolID)
+	if c.reverseDNS != nil && myHost.Name == "" {
+		myHost.Name = c.reverseDNS.Lookup(myHost.IP)
+	}
+	if c.inventory != nil {
+		myHost.Inventory, _ = c.inventory.Lookup(myHost.IP, myHost.MAC)
+	}
+	if c.ouiDB != nil {
+		myHost.Vendor = c.ouiDB.Lookup(myHost.MAC)
+	}
+	if c.geoIP != nil {
+		var err error
+		if myHost.Geo, err = c.geoIP.Lookup(myHost.IP); err != nil {
+			fmt.Printf("GeoIP lookup for host '%s' failed: %v\n", myHost.IP, err)
+		}
+	}
+}
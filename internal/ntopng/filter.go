@@ -0,0 +1,171 @@
+package ntopng
+
+import (
+	"net"
+	"regexp"
+	"strings"
+
+	"github.com/aauren/ntopng-exporter/internal"
+	"github.com/aauren/ntopng-exporter/internal/config"
+)
+
+// filterSubject contains the attributes of an ntopng entity that host filter rules are able to match against
+type filterSubject struct {
+	ip   string
+	mac  string
+	name string
+	// inventoryName is the hostname that the inventory has for the subject, name filters match against it as well
+	inventoryName string
+	vlan          int
+	ifName        string
+	bytes         float64
+}
+
+type hostFilterRule struct {
+	name        string
+	subnets     []*net.IPNet
+	macPrefixes []string
+	nameRegex   *regexp.Regexp
+	vlans       []int
+	interfaces  []string
+	minBytes    float64
+	// exclude is set for the rules of the exclude list, where minBytes matches the subjects below it instead
+	exclude bool
+}
+
+type hostFilter struct {
+	localSubnets []*net.IPNet
+	include      []hostFilterRule
+	exclude      []hostFilterRule
+}
+
+func newHostFilter(config *config.Config) *hostFilter {
+	filter := &hostFilter{
+		localSubnets: parseSubnets(config.Metric.LocalSubnetsOnly),
+	}
+	for _, rule := range config.Metric.HostFilters.Include {
+		filter.include = append(filter.include, newHostFilterRule(rule.Name, rule.Subnets, rule.MACPrefixes,
+			rule.NameRegex, rule.VLANs, rule.Interfaces, rule.MinBytes, false))
+	}
+	for _, rule := range config.Metric.HostFilters.Exclude {
+		filter.exclude = append(filter.exclude, newHostFilterRule(rule.Name, rule.Subnets, rule.MACPrefixes,
+			rule.NameRegex, rule.VLANs, rule.Interfaces, rule.MinBytes, true))
+	}
+	return filter
+}
+
+func newHostFilterRule(name string, subnets []string, macPrefixes []string, nameRegex string, vlans []int,
+	interfaces []string, minBytes float64, exclude bool) hostFilterRule {
+	rule := hostFilterRule{
+		name:       name,
+		subnets:    parseSubnets(subnets),
+		vlans:      vlans,
+		interfaces: interfaces,
+		minBytes:   minBytes,
+		exclude:    exclude,
+	}
+	for _, prefix := range macPrefixes {
+		rule.macPrefixes = append(rule.macPrefixes, internal.NormalizeMAC(prefix))
+	}
+	if nameRegex != "" {
+		// The regex has already been checked during config validation
+		rule.nameRegex = regexp.MustCompile(nameRegex)
+	}
+	return rule
+}
+
+// evaluate returns whether the subject should be kept, and if not, the name of the rule that removed it. Rules that
+// match on subnets are skipped for subjects that do not have an IP address.
+func (f *hostFilter) evaluate(subject *filterSubject) (bool, string) {
+	if len(f.localSubnets) > 0 && subject.ip != "" && !subnetsContain(f.localSubnets, subject.ip) {
+		return false, config.LocalSubnetsFilterRule
+	}
+	evaluatedIncludes, matchedInclude := 0, false
+	for i := range f.include {
+		if !f.include[i].appliesTo(subject) {
+			continue
+		}
+		evaluatedIncludes++
+		if f.include[i].matches(subject) {
+			matchedInclude = true
+			break
+		}
+	}
+	if evaluatedIncludes > 0 && !matchedInclude {
+		return false, config.UnmatchedIncludeFilterRule
+	}
+	for i := range f.exclude {
+		if f.exclude[i].appliesTo(subject) && f.exclude[i].matches(subject) {
+			return false, f.exclude[i].name
+		}
+	}
+	return true, ""
+}
+
+func (r *hostFilterRule) appliesTo(subject *filterSubject) bool {
+	return subject.ip != "" || len(r.subnets) < 1
+}
+
+// matches returns true only if every criteria that the rule defines matches the subject. minBytes is a threshold that
+// subjects need to reach to be kept, so it matches the subjects at or above it in include rules and the subjects below
+// it in exclude rules.
+func (r *hostFilterRule) matches(subject *filterSubject) bool {
+	if len(r.subnets) > 0 && !subnetsContain(r.subnets, subject.ip) {
+		return false
+	}
+	if len(r.macPrefixes) > 0 {
+		mac := internal.NormalizeMAC(subject.mac)
+		matchedPrefix := false
+		for _, prefix := range r.macPrefixes {
+			if strings.HasPrefix(mac, prefix) {
+				matchedPrefix = true
+				break
+			}
+		}
+		if !matchedPrefix {
+			return false
+		}
+	}
+	if r.nameRegex != nil && !r.nameRegex.MatchString(subject.name) &&
+		(subject.inventoryName == "" || !r.nameRegex.MatchString(subject.inventoryName)) {
+		return false
+	}
+	if len(r.vlans) > 0 {
+		matchedVLAN := false
+		for _, vlan := range r.vlans {
+			if vlan == subject.vlan {
+				matchedVLAN = true
+				break
+			}
+		}
+		if !matchedVLAN {
+			return false
+		}
+	}
+	if len(r.interfaces) > 0 && !internal.IsItemInArray(r.interfaces, subject.ifName) {
+		return false
+	}
+	if r.minBytes > 0 && (subject.bytes < r.minBytes) != r.exclude {
+		return false
+	}
+	return true
+}
+
+func parseSubnets(subnets []string) []*net.IPNet {
+	var parsedSubnets []*net.IPNet
+	for _, subnet := range subnets {
+		_, parsedSubnet, _ := net.ParseCIDR(subnet)
+		parsedSubnets = append(parsedSubnets, parsedSubnet)
+	}
+	return parsedSubnets
+}
+
+func subnetsContain(subnets []*net.IPNet, ip string) bool {
+	parsedIP := net.ParseIP(ip)
+	for _, subnet := range subnets {
+		if subnet.Contains(parsedIP) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,162 @@
+package ntopng
+
+import (
+	"testing"
+
+	"github.com/aauren/ntopng-exporter/internal/config"
+)
+
+func TestHostFilterEvaluate(t *testing.T) {
+	tests := []struct {
+		name         string
+		localSubnets []string
+		include      []map[string]any
+		exclude      []map[string]any
+		subject      filterSubject
+		wantKeep     bool
+		wantRule     string
+	}{
+		{name: "no rules", subject: filterSubject{ip: "10.0.0.1"}, wantKeep: true},
+		{
+			name:         "inside local subnets",
+			localSubnets: []string{"10.0.0.0/24"},
+			subject:      filterSubject{ip: "10.0.0.1"},
+			wantKeep:     true,
+		},
+		{
+			name:         "outside local subnets",
+			localSubnets: []string{"10.0.0.0/24"},
+			subject:      filterSubject{ip: "10.0.1.1"},
+			wantRule:     config.LocalSubnetsFilterRule,
+		},
+		{
+			name:         "local subnets skipped without an IP",
+			localSubnets: []string{"10.0.0.0/24"},
+			subject:      filterSubject{mac: "AA:BB:CC:00:00:01"},
+			wantKeep:     true,
+		},
+		{
+			name:     "include subnet",
+			include:  []map[string]any{{"name": "lan", "subnets": []string{"10.0.0.0/24", "fd00::/8"}}},
+			subject:  filterSubject{ip: "fd00::1"},
+			wantKeep: true,
+		},
+		{
+			name:     "unmatched include subnet",
+			include:  []map[string]any{{"name": "lan", "subnets": []string{"10.0.0.0/24"}}},
+			subject:  filterSubject{ip: "192.168.0.1"},
+			wantRule: config.UnmatchedIncludeFilterRule,
+		},
+		{
+			name:     "include subnet skipped without an IP",
+			include:  []map[string]any{{"name": "lan", "subnets": []string{"10.0.0.0/24"}}},
+			subject:  filterSubject{mac: "AA:BB:CC:00:00:01"},
+			wantKeep: true,
+		},
+		{
+			name: "include rules without subnets still apply without an IP",
+			include: []map[string]any{{"name": "lan", "subnets": []string{"10.0.0.0/24"}},
+				{"name": "vlan", "vlans": []int{10}}},
+			subject:  filterSubject{mac: "AA:BB:CC:00:00:01", vlan: 20},
+			wantRule: config.UnmatchedIncludeFilterRule,
+		},
+		{
+			name:     "exclude subnet skipped without an IP",
+			exclude:  []map[string]any{{"name": "lan", "subnets": []string{"10.0.0.0/24"}}},
+			subject:  filterSubject{mac: "AA:BB:CC:00:00:01"},
+			wantKeep: true,
+		},
+		{
+			name:     "exclude name regex",
+			exclude:  []map[string]any{{"name": "phones", "nameRegex": "^android-.*"}},
+			subject:  filterSubject{ip: "10.0.0.1", name: "android-1234"},
+			wantRule: "phones",
+		},
+		{
+			name:     "exclude name regex matching the inventory name",
+			exclude:  []map[string]any{{"name": "phones", "nameRegex": "^android-.*"}},
+			subject:  filterSubject{ip: "10.0.0.1", name: "10.0.0.1", inventoryName: "android-1234"},
+			wantRule: "phones",
+		},
+		{
+			name:     "name regex that doesn't match",
+			exclude:  []map[string]any{{"name": "phones", "nameRegex": "^android-.*"}},
+			subject:  filterSubject{ip: "10.0.0.1", name: "my-android-1234"},
+			wantKeep: true,
+		},
+		{
+			name:     "exclude MAC prefix in another format",
+			exclude:  []map[string]any{{"name": "virtual-machines", "macPrefixes": []string{"52-54-00"}}},
+			subject:  filterSubject{ip: "10.0.0.1", mac: "52:54:00:12:34:56"},
+			wantRule: "virtual-machines",
+		},
+		{
+			name:     "include VLAN",
+			include:  []map[string]any{{"name": "vlan", "vlans": []int{10, 20}}},
+			subject:  filterSubject{ip: "10.0.0.1", vlan: 20},
+			wantKeep: true,
+		},
+		{
+			name:     "unmatched include VLAN",
+			include:  []map[string]any{{"name": "vlan", "vlans": []int{10, 20}}},
+			subject:  filterSubject{ip: "10.0.0.1", vlan: 0},
+			wantRule: config.UnmatchedIncludeFilterRule,
+		},
+		{
+			name:     "include minBytes keeps hosts at the threshold",
+			include:  []map[string]any{{"name": "active", "minBytes": 1024}},
+			subject:  filterSubject{ip: "10.0.0.1", bytes: 1024},
+			wantKeep: true,
+		},
+		{
+			name:     "include minBytes drops hosts below the threshold",
+			include:  []map[string]any{{"name": "active", "minBytes": 1024}},
+			subject:  filterSubject{ip: "10.0.0.1", bytes: 1023},
+			wantRule: config.UnmatchedIncludeFilterRule,
+		},
+		{
+			name:     "exclude minBytes keeps hosts at the threshold",
+			exclude:  []map[string]any{{"name": "idle", "minBytes": 1024}},
+			subject:  filterSubject{ip: "10.0.0.1", bytes: 1024},
+			wantKeep: true,
+		},
+		{
+			name:     "exclude minBytes drops hosts below the threshold",
+			exclude:  []map[string]any{{"name": "idle", "minBytes": 1024}},
+			subject:  filterSubject{ip: "10.0.0.1", bytes: 1023},
+			wantRule: "idle",
+		},
+		{
+			name:     "exclude minBytes only drops hosts that match every criteria",
+			exclude:  []map[string]any{{"name": "idle-guests", "vlans": []int{30}, "minBytes": 1024}},
+			subject:  filterSubject{ip: "10.0.0.1", vlan: 0, bytes: 10},
+			wantKeep: true,
+		},
+		{
+			name:     "exclude is evaluated after include",
+			include:  []map[string]any{{"name": "lan", "subnets": []string{"10.0.0.0/24"}}},
+			exclude:  []map[string]any{{"name": "eth1", "interfaces": []string{"eth1"}}},
+			subject:  filterSubject{ip: "10.0.0.1", ifName: "eth1"},
+			wantRule: "eth1",
+		},
+		{
+			name:     "unmatched include is reported before exclude",
+			include:  []map[string]any{{"name": "lan", "subnets": []string{"10.0.0.0/24"}}},
+			exclude:  []map[string]any{{"name": "eth1", "interfaces": []string{"eth1"}}},
+			subject:  filterSubject{ip: "10.0.1.1", ifName: "eth1"},
+			wantRule: config.UnmatchedIncludeFilterRule,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			filterConfig := &config.Config{}
+			filterConfig.Metric.LocalSubnetsOnly = tt.localSubnets
+			unmarshalConfigKey(t, "include", tt.include, &filterConfig.Metric.HostFilters.Include)
+			unmarshalConfigKey(t, "exclude", tt.exclude, &filterConfig.Metric.HostFilters.Exclude)
+			keep, rule := newHostFilter(filterConfig).evaluate(&tt.subject)
+			if keep != tt.wantKeep || rule != tt.wantRule {
+				t.Errorf("evaluate() = %t, %q, want %t, %q", keep, rule, tt.wantKeep, tt.wantRule)
+			}
+		})
+	}
+}
@@ -0,0 +1,181 @@
+package ntopng
+
+import (
+	"slices"
+	"sort"
+
+	"github.com/aauren/ntopng-exporter/internal/config"
+)
+
+var hostLimitSortFields = map[string]func(*NtopHost) float64{
+	"bytes":        func(h *NtopHost) float64 { return h.BytesSent + h.BytesReceived },
+	"bytes.sent":   func(h *NtopHost) float64 { return h.BytesSent },
+	"bytes.rcvd":   func(h *NtopHost) float64 { return h.BytesReceived },
+	"packets":      func(h *NtopHost) float64 { return h.PacketsSent + h.PacketsReceived },
+	"packets.sent": func(h *NtopHost) float64 { return h.PacketsSent },
+	"packets.rcvd": func(h *NtopHost) float64 { return h.PacketsReceived },
+	"active_flows": func(h *NtopHost) float64 { return h.ActiveFlowsAsClient + h.ActiveFlowsAsServer },
+	"total_flows":  func(h *NtopHost) float64 { return h.TotalFlowsAsClient + h.TotalFlowsAsServer },
+	"total_alerts": func(h *NtopHost) float64 { return h.TotalAlerts },
+}
+
+// otherHostTotals keeps what is needed to make the counters of an interface's aggregate "other" host monotonic
+type otherHostTotals struct {
+	// totals holds the counters of the "other" host, which only ever grow by the increases of the hosts within it
+	totals NtopHost
+	// previous holds the last scraped values of every host that was kept on the interface, keyed by IP
+	previous map[string]NtopHost
+	// exported holds the counters that every host on the interface was last exported with, keyed by IP. The counters of
+	// hosts within the "other" host stay at the values they had when they were last among the top hosts.
+	exported map[string]NtopHost
+}
+
+// limitHosts keeps the configured maximum number of hosts from a single interface, ranked by the configured sort field,
+// and sums all of the remaining hosts into a single aggregate host so that interface totals are preserved. If no limit
+// is configured or the interface does not have more hosts than the limit, the hosts are returned unchanged.
+//
+// The hosts within the aggregate change whenever a host moves into the top hosts or ntopng purges an idle one, so
+// summing their counters would make the aggregate's counters drop. Instead, the aggregate's counters accumulate the
+// increase of each of its hosts since the previous scrape. As those increases have already been counted by the
+// aggregate, a host that moves back into the top hosts continues from the counters it was last exported with rather
+// than from ntopng's values, so that they aren't counted twice.
+func (c *Controller) limitHosts(hosts []NtopHost) (kept []NtopHost, other *NtopHost) {
+	maxHosts := c.config.Metric.HostLimit.MaxHosts
+	if maxHosts < 1 || len(hosts) < 1 {
+		return hosts, nil
+	}
+	ifName := hosts[0].IfName
+	if c.otherHosts == nil {
+		c.otherHosts = make(map[string]*otherHostTotals)
+	}
+	otherTotals, ok := c.otherHosts[ifName]
+	if !ok {
+		otherTotals = &otherHostTotals{}
+		c.otherHosts[ifName] = otherTotals
+	}
+	previous, exported := otherTotals.previous, otherTotals.exported
+	otherTotals.previous = make(map[string]NtopHost, len(hosts))
+	otherTotals.exported = make(map[string]NtopHost, len(hosts))
+	for i := range hosts {
+		// The custom values are copied, as they are changed in place when the host's counters are continued
+		previousHost := hosts[i]
+		previousHost.Custom = slices.Clone(hosts[i].Custom)
+		otherTotals.previous[hosts[i].IP] = previousHost
+	}
+	keptHosts := min(len(hosts), maxHosts)
+	if len(hosts) > maxHosts {
+		sortField := hostLimitSortFields[c.config.Metric.HostLimit.SortBy]
+		sort.SliceStable(hosts, func(i, j int) bool {
+			iVal, jVal := sortField(&hosts[i]), sortField(&hosts[j])
+			if iVal != jVal {
+				return iVal > jVal
+			}
+			return hosts[i].IP < hosts[j].IP
+		})
+		other = &NtopHost{
+			IP:     config.OtherHostIP,
+			IfID:   hosts[maxHosts].IfID,
+			IfName: ifName,
+		}
+	}
+	for i := range hosts {
+		var previousHost *NtopHost
+		if host, ok := previous[hosts[i].IP]; ok {
+			previousHost = &host
+		}
+		exportedHost := exported[hosts[i].IP]
+		if i < keptHosts {
+			c.continueCounters(&hosts[i], &exportedHost, previousHost)
+			exportedHost = hosts[i]
+			exportedHost.Custom = slices.Clone(hosts[i].Custom)
+		} else {
+			other.add(&hosts[i])
+			c.addCounterIncreases(&otherTotals.totals, &hosts[i], previousHost)
+		}
+		otherTotals.exported[hosts[i].IP] = exportedHost
+	}
+	if other == nil {
+		return hosts, nil
+	}
+	c.setCounters(other, &otherTotals.totals)
+	return hosts[:maxHosts], other
+}
+
+// continueCounters makes the counters of a kept host continue from the counters it was last exported with, by adding
+// how much each of them has increased since the previous scrape. A host that has been kept all along thereby keeps
+// ntopng's values. A host that wasn't scraped before, or whose counter went down because ntopng reset it, uses ntopng's
+// value like the "other" host does.
+func (c *Controller) continueCounters(host *NtopHost, exported *NtopHost, previous *NtopHost) {
+	if previous == nil {
+		return
+	}
+	hostCounters, exportedCounters, previousCounters := host.counters(), exported.counters(), previous.counters()
+	for i := range hostCounters {
+		if current := *hostCounters[i].value; current >= *previousCounters[i].value {
+			*hostCounters[i].value = *exportedCounters[i].value + current - *previousCounters[i].value
+		}
+	}
+	for i := range host.Custom {
+		currentValue := &host.Custom[i]
+		if !c.config.Metric.CustomHostFields[currentValue.Field].IsCounter() {
+			continue
+		}
+		previousValue := previous.customValue(currentValue.Field, currentValue.Keys)
+		if previousValue == nil || currentValue.Value < previousValue.Value {
+			continue
+		}
+		var exportedValue float64
+		if value := exported.customValue(currentValue.Field, currentValue.Keys); value != nil {
+			exportedValue = value.Value
+		}
+		currentValue.Value = exportedValue + currentValue.Value - previousValue.Value
+	}
+}
+
+// addCounterIncreases adds how much each counter of the host has increased since the previous scrape onto totals. A host
+// that wasn't scraped before, or whose counter went down because ntopng reset it, adds its whole value.
+func (c *Controller) addCounterIncreases(totals *NtopHost, current *NtopHost, previous *NtopHost) {
+	totalCounters, currentCounters := totals.counters(), current.counters()
+	var previousCounters []namedCounter
+	if previous != nil {
+		previousCounters = previous.counters()
+	}
+	for i := range totalCounters {
+		*totalCounters[i].value += counterIncrease(currentCounters, previousCounters, i)
+	}
+	for _, currentValue := range current.Custom {
+		if !c.config.Metric.CustomHostFields[currentValue.Field].IsCounter() {
+			continue
+		}
+		increase := currentValue.Value
+		if previous != nil {
+			if previousValue := previous.customValue(currentValue.Field, currentValue.Keys); previousValue != nil &&
+				currentValue.Value >= previousValue.Value {
+				increase = currentValue.Value - previousValue.Value
+			}
+		}
+		totals.addCustomValue(CustomHostValue{Field: currentValue.Field, Keys: currentValue.Keys, Value: increase})
+	}
+}
+
+func counterIncrease(current []namedCounter, previous []namedCounter, index int) float64 {
+	if previous == nil || *current[index].value < *previous[index].value {
+		return *current[index].value
+	}
+	return *current[index].value - *previous[index].value
+}
+
+// setCounters replaces the counters of the host, including its custom counters, with those of totals
+func (c *Controller) setCounters(host *NtopHost, totals *NtopHost) {
+	hostCounters, totalCounters := host.counters(), totals.counters()
+	for i := range hostCounters {
+		*hostCounters[i].value = *totalCounters[i].value
+	}
+	customValues := host.Custom[:0]
+	for _, value := range host.Custom {
+		if !c.config.Metric.CustomHostFields[value.Field].IsCounter() {
+			customValues = append(customValues, value)
+		}
+	}
+	host.Custom = append(customValues, totals.Custom...)
+}
@@ -0,0 +1,170 @@
+package ntopng
+
+import (
+	"testing"
+
+	"github.com/aauren/ntopng-exporter/internal/config"
+)
+
+func TestLimitHostsOtherCountersAreMonotonic(t *testing.T) {
+	c := &Controller{config: &config.Config{}}
+	c.config.Metric.HostLimit.MaxHosts = 1
+	c.config.Metric.HostLimit.SortBy = "bytes"
+	host := func(ip string, bytesSent float64, activeFlows float64) NtopHost {
+		return NtopHost{IP: ip, IfName: "eth0", BytesSent: bytesSent, ActiveFlowsAsClient: activeFlows}
+	}
+	scrapes := []struct {
+		name              string
+		hosts             []NtopHost
+		wantBytesSent     float64
+		wantActiveFlows   float64
+		wantOtherExported bool
+	}{
+		{
+			name:              "first scrape sums the hosts",
+			hosts:             []NtopHost{host("10.0.0.1", 1000, 1), host("10.0.0.2", 100, 2), host("10.0.0.3", 50, 3)},
+			wantBytesSent:     150,
+			wantActiveFlows:   5,
+			wantOtherExported: true,
+		},
+		{
+			name:              "increases are added",
+			hosts:             []NtopHost{host("10.0.0.1", 1000, 1), host("10.0.0.2", 120, 2), host("10.0.0.3", 60, 1)},
+			wantBytesSent:     180,
+			wantActiveFlows:   3,
+			wantOtherExported: true,
+		},
+		{
+			name:              "purged host doesn't make the counter drop",
+			hosts:             []NtopHost{host("10.0.0.1", 1000, 1), host("10.0.0.2", 130, 2)},
+			wantBytesSent:     190,
+			wantActiveFlows:   2,
+			wantOtherExported: true,
+		},
+		{
+			name:              "host moving into the top hosts doesn't make the counter drop",
+			hosts:             []NtopHost{host("10.0.0.1", 1000, 1), host("10.0.0.2", 2000, 2)},
+			wantBytesSent:     190,
+			wantActiveFlows:   1,
+			wantOtherExported: true,
+		},
+		{
+			name:              "reset counter adds its whole value",
+			hosts:             []NtopHost{host("10.0.0.1", 10, 1), host("10.0.0.2", 2000, 2)},
+			wantBytesSent:     200,
+			wantActiveFlows:   1,
+			wantOtherExported: true,
+		},
+		{
+			name:              "no aggregate when there are no more hosts than the limit",
+			hosts:             []NtopHost{host("10.0.0.2", 2000, 2)},
+			wantOtherExported: false,
+		},
+		{
+			name:              "totals are kept while the aggregate isn't exported",
+			hosts:             []NtopHost{host("10.0.0.1", 30, 1), host("10.0.0.2", 2000, 2)},
+			wantBytesSent:     230,
+			wantActiveFlows:   1,
+			wantOtherExported: true,
+		},
+	}
+	for _, scrape := range scrapes {
+		kept, other := c.limitHosts(scrape.hosts)
+		if !scrape.wantOtherExported {
+			if other != nil {
+				t.Errorf("%s: expected no other host, got %+v", scrape.name, other)
+			}
+			continue
+		}
+		if len(kept) != 1 || other == nil {
+			t.Fatalf("%s: expected 1 kept host and an other host, got %d kept and %v", scrape.name, len(kept), other)
+		}
+		if other.BytesSent != scrape.wantBytesSent {
+			t.Errorf("%s: other bytes sent = %v, want %v", scrape.name, other.BytesSent, scrape.wantBytesSent)
+		}
+		if other.ActiveFlowsAsClient != scrape.wantActiveFlows {
+			t.Errorf("%s: other active flows = %v, want %v", scrape.name, other.ActiveFlowsAsClient,
+				scrape.wantActiveFlows)
+		}
+	}
+}
+
+func TestLimitHostsReenteringHostIsNotCountedTwice(t *testing.T) {
+	c := &Controller{config: &config.Config{}}
+	c.config.Metric.HostLimit.MaxHosts = 1
+	c.config.Metric.HostLimit.SortBy = "bytes"
+	unmarshalConfigKey(t, "fields", []map[string]any{{"field": "ndpi", "path": "*.bytes", "name": "l7_bytes",
+		"type": config.CounterMetricType, "keyLabels": []string{"protocol"}}}, &c.config.Metric.CustomHostFields)
+	host := func(ip string, bytesSent float64) NtopHost {
+		return NtopHost{IP: ip, IfName: "eth0", BytesSent: bytesSent,
+			Custom: []CustomHostValue{{Field: 0, Keys: []string{"TLS"}, Value: bytesSent}}}
+	}
+	scrapes := []struct {
+		name          string
+		hosts         []NtopHost
+		wantKeptIP    string
+		wantKeptBytes float64
+		wantOther     float64
+	}{
+		{
+			name:          "first scrape exports ntopng's values",
+			hosts:         []NtopHost{host("10.0.0.1", 1000), host("10.0.0.2", 100)},
+			wantKeptIP:    "10.0.0.1",
+			wantKeptBytes: 1000,
+			wantOther:     100,
+		},
+		{
+			name:          "host that was only within other starts from its increase",
+			hosts:         []NtopHost{host("10.0.0.1", 1010), host("10.0.0.2", 2000)},
+			wantKeptIP:    "10.0.0.2",
+			wantKeptBytes: 1900,
+			wantOther:     110,
+		},
+		{
+			name:          "increases within other are counted by other",
+			hosts:         []NtopHost{host("10.0.0.1", 1500), host("10.0.0.2", 2005)},
+			wantKeptIP:    "10.0.0.2",
+			wantKeptBytes: 1905,
+			wantOther:     600,
+		},
+		{
+			name:          "host moving back into the top hosts continues from its last exported value",
+			hosts:         []NtopHost{host("10.0.0.1", 3000), host("10.0.0.2", 2010)},
+			wantKeptIP:    "10.0.0.1",
+			wantKeptBytes: 2500,
+			wantOther:     605,
+		},
+		{
+			name:          "kept host continues with ntopng's increases",
+			hosts:         []NtopHost{host("10.0.0.1", 3100), host("10.0.0.2", 2020)},
+			wantKeptIP:    "10.0.0.1",
+			wantKeptBytes: 2600,
+			wantOther:     615,
+		},
+		{
+			name:          "reset counter restarts from ntopng's value",
+			hosts:         []NtopHost{host("10.0.0.1", 2500), host("10.0.0.2", 2030)},
+			wantKeptIP:    "10.0.0.1",
+			wantKeptBytes: 2500,
+			wantOther:     625,
+		},
+	}
+	for _, scrape := range scrapes {
+		kept, other := c.limitHosts(scrape.hosts)
+		if len(kept) != 1 || other == nil {
+			t.Fatalf("%s: expected 1 kept host and an other host, got %d kept and %v", scrape.name, len(kept), other)
+		}
+		if kept[0].IP != scrape.wantKeptIP {
+			t.Fatalf("%s: kept host = %s, want %s", scrape.name, kept[0].IP, scrape.wantKeptIP)
+		}
+		if kept[0].BytesSent != scrape.wantKeptBytes {
+			t.Errorf("%s: kept bytes sent = %v, want %v", scrape.name, kept[0].BytesSent, scrape.wantKeptBytes)
+		}
+		if kept[0].Custom[0].Value != scrape.wantKeptBytes {
+			t.Errorf("%s: kept custom value = %v, want %v", scrape.name, kept[0].Custom[0].Value, scrape.wantKeptBytes)
+		}
+		if other.BytesSent != scrape.wantOther {
+			t.Errorf("%s: other bytes sent = %v, want %v", scrape.name, other.BytesSent, scrape.wantOther)
+		}
+	}
+}
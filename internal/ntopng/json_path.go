@@ -0,0 +1,109 @@
+package ntopng
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const jsonPathWildcard = "*"
+
+// jsonPathMatch is a value found by lookupJSONPath along with the keys that each wildcard in the path matched
+type jsonPathMatch struct {
+	keys  []string
+	value any
+}
+
+// lookupJSONPath finds all of the values at path within a decoded JSON document. Path segments are separated by dots,
+// but as ntopng uses dots within its own keys (e.g. bytes.sent), the longest run of segments that matches a key is
+// used at each level. A * segment matches every key of an object or every item of an array, and a number selects a
+// single item of an array. An empty path matches the document itself.
+func lookupJSONPath(document any, path string) []jsonPathMatch {
+	if path == "" {
+		return []jsonPathMatch{{value: document}}
+	}
+	return lookupJSONPathSegments(document, strings.Split(path, "."), nil)
+}
+
+func lookupJSONPathSegments(document any, segments []string, keys []string) []jsonPathMatch {
+	if len(segments) < 1 {
+		return []jsonPathMatch{{keys: keys, value: document}}
+	}
+	switch typed := document.(type) {
+	case map[string]any:
+		if segments[0] == jsonPathWildcard {
+			objectKeys := make([]string, 0, len(typed))
+			for key := range typed {
+				objectKeys = append(objectKeys, key)
+			}
+			sort.Strings(objectKeys)
+			var matches []jsonPathMatch
+			for _, key := range objectKeys {
+				matches = append(matches, lookupJSONPathSegments(typed[key], segments[1:], appendKey(keys, key))...)
+			}
+			return matches
+		}
+		// Wildcards are never part of a key, so the longest key we try stops at the next one
+		keyEnd := len(segments)
+		for i, segment := range segments {
+			if segment == jsonPathWildcard {
+				keyEnd = i
+				break
+			}
+		}
+		for end := keyEnd; end > 0; end-- {
+			if value, ok := typed[strings.Join(segments[:end], ".")]; ok {
+				return lookupJSONPathSegments(value, segments[end:], keys)
+			}
+		}
+	case []any:
+		if segments[0] == jsonPathWildcard {
+			var matches []jsonPathMatch
+			for i, item := range typed {
+				matches = append(matches, lookupJSONPathSegments(item, segments[1:], appendKey(keys, strconv.Itoa(i)))...)
+			}
+			return matches
+		}
+		if index, err := strconv.Atoi(segments[0]); err == nil && index >= 0 && index < len(typed) {
+			return lookupJSONPathSegments(typed[index], segments[1:], keys)
+		}
+	}
+	return nil
+}
+
+func appendKey(keys []string, key string) []string {
+	newKeys := make([]string, len(keys), len(keys)+1)
+	copy(newKeys, keys)
+	return append(newKeys, key)
+}
+
+// jsonValueToFloat converts a decoded JSON value into a metric value, numeric strings are parsed and booleans become 1
+// or 0. Any other type of value returns false.
+func jsonValueToFloat(value any) (float64, bool) {
+	switch typed := value.(type) {
+	case float64:
+		return typed, true
+	case bool:
+		if typed {
+			return 1, true
+		}
+		return 0, true
+	case string:
+		parsed, err := strconv.ParseFloat(typed, 64)
+		return parsed, err == nil
+	}
+	return 0, false
+}
+
+// jsonValueToString converts a decoded JSON value into a label value, objects, arrays and nulls become an empty string
+func jsonValueToString(value any) string {
+	switch typed := value.(type) {
+	case string:
+		return typed
+	case float64:
+		return strconv.FormatFloat(typed, 'f', -1, 64)
+	case bool:
+		return strconv.FormatBool(typed)
+	}
+	return ""
+}
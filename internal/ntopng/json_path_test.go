@@ -0,0 +1,112 @@
+package ntopng
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestLookupJSONPath(t *testing.T) {
+	var document any
+	if err := json.Unmarshal([]byte(`{
+		"bytes.sent": 100,
+		"bytes": {"rcvd": 200},
+		"ndpi": {
+			"TLS": {"bytes.sent": 10, "bytes.rcvd": 20, "breed": "Safe"},
+			"HTTP": {"bytes.sent": 30, "bytes.rcvd": 40, "breed": "Acceptable"}
+		},
+		"alerts": [{"type": "flow", "count": 3}, {"type": "host", "count": 4}],
+		"groups": {"a": [1, 2], "b": [3]}
+	}`), &document); err != nil {
+		t.Fatalf("was not able to parse document: %v", err)
+	}
+	tests := []struct {
+		name string
+		path string
+		want []jsonPathMatch
+	}{
+		{name: "empty path matches the document", path: "", want: []jsonPathMatch{{value: document}}},
+		{name: "key containing a dot", path: "bytes.sent", want: []jsonPathMatch{{value: 100.0}}},
+		{name: "nested key", path: "bytes.rcvd", want: []jsonPathMatch{{value: 200.0}}},
+		{
+			name: "wildcard followed by a key containing a dot",
+			path: "ndpi.*.bytes.sent",
+			want: []jsonPathMatch{{keys: []string{"HTTP"}, value: 30.0}, {keys: []string{"TLS"}, value: 10.0}},
+		},
+		{
+			name: "array wildcard",
+			path: "alerts.*.count",
+			want: []jsonPathMatch{{keys: []string{"0"}, value: 3.0}, {keys: []string{"1"}, value: 4.0}},
+		},
+		{name: "array index", path: "alerts.1.type", want: []jsonPathMatch{{value: "host"}}},
+		{
+			name: "nested wildcards",
+			path: "groups.*.*",
+			want: []jsonPathMatch{{keys: []string{"a", "0"}, value: 1.0}, {keys: []string{"a", "1"}, value: 2.0},
+				{keys: []string{"b", "0"}, value: 3.0}},
+		},
+		{name: "out of range index", path: "alerts.2.count"},
+		{name: "negative index", path: "alerts.-1.count"},
+		{name: "index into an object", path: "ndpi.0"},
+		{name: "missing key", path: "ndpi.SSH.bytes.sent"},
+		{name: "path below a value", path: "bytes.sent.total"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := lookupJSONPath(document, tt.path)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("lookupJSONPath(%q) = %+v, want %+v", tt.path, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestJSONValueToFloat(t *testing.T) {
+	tests := []struct {
+		name   string
+		value  any
+		want   float64
+		wantOK bool
+	}{
+		{name: "number", value: 1.5, want: 1.5, wantOK: true},
+		{name: "true", value: true, want: 1, wantOK: true},
+		{name: "false", value: false, want: 0, wantOK: true},
+		{name: "numeric string", value: "42", want: 42, wantOK: true},
+		{name: "non-numeric string", value: "n/a"},
+		{name: "null", value: nil},
+		{name: "object", value: map[string]any{"a": 1.0}},
+		{name: "array", value: []any{1.0}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := jsonValueToFloat(tt.value)
+			if got != tt.want || ok != tt.wantOK {
+				t.Errorf("jsonValueToFloat(%v) = %v, %t, want %v, %t", tt.value, got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestJSONValueToString(t *testing.T) {
+	tests := []struct {
+		name  string
+		value any
+		want  string
+	}{
+		{name: "string", value: "eth0", want: "eth0"},
+		{name: "integer", value: 3.0, want: "3"},
+		{name: "fraction", value: 0.25, want: "0.25"},
+		{name: "large number", value: 1e21, want: "1000000000000000000000"},
+		{name: "bool", value: true, want: "true"},
+		{name: "null", value: nil, want: ""},
+		{name: "object", value: map[string]any{"a": "b"}, want: ""},
+		{name: "array", value: []any{"a"}, want: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := jsonValueToString(tt.value); got != tt.want {
+				t.Errorf("jsonValueToString(%v) = %q, want %q", tt.value, got, tt.want)
+			}
+		})
+	}
+}
@@ -2,6 +2,10 @@ package ntopng
 
 import (
 	"encoding/json"
+	"slices"
+	"time"
+
+	"github.com/aauren/ntopng-exporter/internal/enrichment"
 )
 
 type ntopResponse struct {
@@ -15,24 +19,70 @@ type ntopInterface struct {
 	IfName string `json:"ifname"`
 }
 
-type ntopHost struct {
-	ActiveFlowsAsClient float64 `json:"active_flows.as_client"`
-	ActiveFlowsAsServer float64 `json:"active_flows.as_server"`
-	BytesReceived       float64 `json:"bytes.rcvd"`
-	BytesSent           float64 `json:"bytes.sent"`
-	DNS                 ntopDNS `json:"dns"`
-	IfID                int     `json:"ifid"`
-	IfName              string  `json:"ifname"`
-	IP                  string  `json:"IP"`
-	MAC                 string  `json:"mac"`
-	Name                string  `json:"name"`
-	NumAlerts           float64 `json:"num_alerts"`
-	PacketsReceived     float64 `json:"packets.rcvd"`
-	PacketsSent         float64 `json:"packets.sent"`
-	TotalAlerts         float64 `json:"total_alerts"`
-	TotalFlowsAsClient  float64 `json:"total_flows.as_client"`
-	TotalFlowsAsServer  float64 `json:"total_flows.as_server"`
-	VLAN                int     `json:"vlan"`
+type NtopHost struct {
+	ActiveFlowsAsClient float64                   `json:"active_flows.as_client"`
+	ActiveFlowsAsServer float64                   `json:"active_flows.as_server"`
+	Blacklisted         bool                      `json:"is_blacklisted"`
+	BytesReceived       float64                   `json:"bytes.rcvd"`
+	BytesSent           float64                   `json:"bytes.sent"`
+	Custom              []CustomHostValue         `json:"-"`
+	DNS                 ntopDNS                   `json:"dns"`
+	Duration            float64                   `json:"duration"`
+	Geo                 enrichment.GeoInfo        `json:"-"`
+	IfID                int                       `json:"ifid"`
+	IfName              string                    `json:"ifname"`
+	Inventory           enrichment.InventoryEntry `json:"-"`
+	IP                  string                    `json:"IP"`
+	// LastScraped is when ntopng last returned the host to the exporter
+	LastScraped              time.Time          `json:"-"`
+	Localhost                bool               `json:"is_localhost"`
+	MAC                      string             `json:"mac"`
+	MisbehavingFlowsAsClient float64            `json:"misbehaving_flows.as_client"`
+	MisbehavingFlowsAsServer float64            `json:"misbehaving_flows.as_server"`
+	Name                     string             `json:"name"`
+	NumAlerts                float64            `json:"num_alerts"`
+	OS                       string             `json:"os_detail"`
+	PacketsReceived          float64            `json:"packets.rcvd"`
+	PacketsSent              float64            `json:"packets.sent"`
+	Pool                     string             `json:"-"`
+	PoolID                   int                `json:"host_pool_id"`
+	ScoreAsClient            float64            `json:"score.as_client"`
+	ScoreAsServer            float64            `json:"score.as_server"`
+	SeenFirst                float64            `json:"seen.first"`
+	SeenLast                 float64            `json:"seen.last"`
+	TCPPacketStatsReceived   ntopTCPPacketStats `json:"tcpPacketStats.rcvd"`
+	TCPPacketStatsSent       ntopTCPPacketStats `json:"tcpPacketStats.sent"`
+	ThroughputBPS            float64            `json:"throughput_bps"`
+	ThroughputPPS            float64            `json:"throughput_pps"`
+	TotalAlerts              float64            `json:"total_alerts"`
+	TotalFlowsAsClient       float64            `json:"total_flows.as_client"`
+	TotalFlowsAsServer       float64            `json:"total_flows.as_server"`
+	UnreachableFlowsAsClient float64            `json:"unreachable_flows.as_client"`
+	UnreachableFlowsAsServer float64            `json:"unreachable_flows.as_server"`
+	Vendor                   string             `json:"-"`
+	VLAN                     int                `json:"vlan"`
+	// missedScrapes is the number of scrapes in a row that ntopng hasn't returned the host in, while it is retained
+	missedScrapes int
+}
+
+// CustomHostValue is a value extracted for one of the configured custom host fields
+type CustomHostValue struct {
+	// Field is the index of the custom host field within the config
+	Field int
+	// Keys are the keys that were matched by the wildcards of the custom host field's path
+	Keys  []string
+	Value float64
+}
+
+// CustomSample is a single value extracted from one of the configured custom endpoints
+type CustomSample struct {
+	// Endpoint and Metric are the indexes of the custom endpoint and its metric within the config
+	Endpoint int
+	Metric   int
+	// LabelValues are in the order of the metric's LabelNames, followed by the interface name for endpoints that are
+	// requested for each monitored interface
+	LabelValues []string
+	Value       float64
 }
 
 type ntopDNS struct {
@@ -68,6 +118,7 @@ type ntopInterfaceFull struct {
 	BytesReceived       float64            `json:"bytes_download"`
 	BytesSent           float64            `json:"bytes_upload"`
 	Drops               float64            `json:"drops"`
+	HashTables          ntopHashTables     `json:"-"`
 	IfID                string             `json:"ifid"`
 	IfName              string             `json:"ifname"`
 	NumDevices          float64            `json:"num_devices"`
@@ -80,6 +131,15 @@ type ntopInterfaceFull struct {
 	Throughput          ntopThroughput     `json:"throughput"`
 }
 
+// ntopHashTables is keyed by the name of the hash table (e.g. FlowHash, HostHash)
+type ntopHashTables map[string]ntopHashTable
+
+type ntopHashTable struct {
+	// EntryStates is keyed by state with the hash_entry_state_ prefix that ntopng uses (e.g. hash_entry_state_active)
+	EntryStates map[string]float64 `json:"hash_entry_states"`
+	MaxEntries  float64            `json:"max_entries"`
+}
+
 type ntopTCPPacketStats struct {
 	Lost            float64 `json:"lost"`
 	OutOfOrder      float64 `json:"out_of_order"`
@@ -96,7 +156,309 @@ type ntopThroughputSub struct {
 	PPS float64 `json:"pps"`
 }
 
-func (n ntopHost) String() string {
+type ntopAS struct {
+	ASN           int     `json:"asn"`
+	ASName        string  `json:"asname"`
+	BytesReceived float64 `json:"bytes.rcvd"`
+	BytesSent     float64 `json:"bytes.sent"`
+	IfName        string  `json:"ifname"`
+	NumHosts      float64 `json:"num_hosts"`
+	Score         float64 `json:"score"`
+	ThroughputBPS float64 `json:"throughput_bps"`
+}
+
+type ntopCountry struct {
+	Country       string  `json:"country"`
+	EgressBytes   float64 `json:"egress"`
+	IfName        string  `json:"ifname"`
+	IngressBytes  float64 `json:"ingress"`
+	InnerBytes    float64 `json:"inner"`
+	NumHosts      float64 `json:"num_hosts"`
+	Score         float64 `json:"score"`
+	ThroughputBPS float64 `json:"throughput_bps"`
+}
+
+type ntopOS struct {
+	BytesReceived float64 `json:"bytes.rcvd"`
+	BytesSent     float64 `json:"bytes.sent"`
+	IfName        string  `json:"ifname"`
+	NumHosts      float64 `json:"num_hosts"`
+	OS            string  `json:"os"`
+	ThroughputBPS float64 `json:"throughput_bps"`
+}
+
+type NtopMAC struct {
+	ARPRepliesReceived  float64 `json:"arp_replies.rcvd"`
+	ARPRepliesSent      float64 `json:"arp_replies.sent"`
+	ARPRequestsReceived float64 `json:"arp_requests.rcvd"`
+	ARPRequestsSent     float64 `json:"arp_requests.sent"`
+	BytesReceived       float64 `json:"bytes.rcvd"`
+	BytesSent           float64 `json:"bytes.sent"`
+	IfName              string  `json:"ifname"`
+	MAC                 string  `json:"mac"`
+	Manufacturer        string  `json:"manufacturer"`
+	NumHosts            float64 `json:"num_hosts"`
+	PacketsReceived     float64 `json:"packets.rcvd"`
+	PacketsSent         float64 `json:"packets.sent"`
+	SeenFirst           float64 `json:"seen.first"`
+	SeenLast            float64 `json:"seen.last"`
+}
+
+type ntopNetwork struct {
+	EgressBytes   float64 `json:"egress"`
+	IfName        string  `json:"ifname"`
+	IngressBytes  float64 `json:"ingress"`
+	InnerBytes    float64 `json:"inner"`
+	Network       string  `json:"network_key"`
+	NetworkID     int     `json:"network_id"`
+	NumHosts      float64 `json:"num_hosts"`
+	Score         float64 `json:"score"`
+	ThroughputBPS float64 `json:"throughput_bps"`
+}
+
+type ntopPool struct {
+	Members []string `json:"members"`
+	Name    string   `json:"name"`
+	PoolID  int      `json:"pool_id"`
+}
+
+type ntopPoolStats struct {
+	BytesReceived   float64 `json:"bytes.rcvd"`
+	BytesSent       float64 `json:"bytes.sent"`
+	IfName          string  `json:"ifname"`
+	Name            string  `json:"-"`
+	NumHosts        float64 `json:"num_hosts"`
+	PacketsReceived float64 `json:"packets.rcvd"`
+	PacketsSent     float64 `json:"packets.sent"`
+	PoolID          int     `json:"pool_id"`
+	ThroughputBPS   float64 `json:"throughput_bps"`
+}
+
+type ntopFlowDevice struct {
+	Drops               float64 `json:"drops"`
+	ExporterIP          string  `json:"exporter_ip"`
+	Flows               float64 `json:"num_flows"`
+	IfName              string  `json:"ifname"`
+	LastSeen            float64 `json:"seen.last"`
+	ProbeIfIndex        int     `json:"probe_ifindex"`
+	ProbeIP             string  `json:"probe_ip"`
+	ProbeUUID           string  `json:"probe_uuid"`
+	ZMQMessagesDropped  float64 `json:"zmq_msg_drops"`
+	ZMQMessagesReceived float64 `json:"zmq_msg_rcvd"`
+}
+
+type ntopSNMPDevice struct {
+	IP          string         `json:"ip"`
+	Name        string         `json:"name"`
+	Ports       []ntopSNMPPort `json:"-"`
+	Unreachable bool           `json:"unreachable"`
+	Uptime      float64        `json:"uptime_sec"`
+	Vendor      string         `json:"vendor"`
+}
+
+type ntopSNMPPort struct {
+	AdminStatus      string  `json:"admin_status"`
+	Alias            string  `json:"alias"`
+	BytesReceived    float64 `json:"in_bytes"`
+	BytesSent        float64 `json:"out_bytes"`
+	DiscardsReceived float64 `json:"in_discards"`
+	DiscardsSent     float64 `json:"out_discards"`
+	ErrorsReceived   float64 `json:"in_errors"`
+	ErrorsSent       float64 `json:"out_errors"`
+	Index            int     `json:"index"`
+	Name             string  `json:"name"`
+	OperStatus       string  `json:"oper_status"`
+	Speed            float64 `json:"speed"`
+	// UtilizationReceived and UtilizationSent are percentages of the port speed
+	UtilizationReceived float64 `json:"in_util"`
+	UtilizationSent     float64 `json:"out_util"`
+}
+
+type ntopSystemInfo struct {
+	Edition  string      `json:"edition"`
+	License  ntopLicense `json:"license"`
+	Platform string      `json:"platform"`
+	Revision string      `json:"git"`
+	Uptime   float64     `json:"uptime_sec"`
+	Version  string      `json:"version"`
+}
+
+type ntopLicense struct {
+	Expiry float64 `json:"valid_until"`
+	Type   string  `json:"type"`
+	Valid  bool    `json:"is_valid"`
+}
+
+type ntopSystemHealth struct {
+	ClickHouse      ntopServiceStatus             `json:"clickhouse"`
+	CPULoad         float64                       `json:"cpu_load"`
+	HashTables      map[string]ntopHashTableUsage `json:"hash_tables"`
+	MemoryResident  float64                       `json:"mem_ntopng_resident"`
+	MemoryTotal     float64                       `json:"mem_total"`
+	MemoryUsed      float64                       `json:"mem_used"`
+	PeriodicScripts map[string]ntopPeriodicScript `json:"periodic_scripts"`
+	Redis           ntopServiceStatus             `json:"redis"`
+}
+
+type ntopServiceStatus struct {
+	Enabled bool    `json:"enabled"`
+	Keys    float64 `json:"keys"`
+	Memory  float64 `json:"memory"`
+	Running bool    `json:"running"`
+}
+
+type ntopHashTableUsage struct {
+	Entries float64 `json:"entries"`
+	Max     float64 `json:"max"`
+}
+
+type ntopPeriodicScript struct {
+	LastDurationMS float64 `json:"last_duration_ms"`
+	MaxDuration    float64 `json:"max_duration_secs"`
+	NumSkipped     float64 `json:"num_is_skipped"`
+}
+
+func (n *NtopMAC) filterSubject() *filterSubject {
+	return &filterSubject{
+		mac:    n.MAC,
+		ifName: n.IfName,
+		bytes:  n.BytesSent + n.BytesReceived,
+	}
+}
+
+func (n *NtopHost) filterSubject() *filterSubject {
+	return &filterSubject{
+		ip:            n.IP,
+		mac:           n.MAC,
+		name:          n.Name,
+		inventoryName: n.Inventory.Hostname,
+		vlan:          n.VLAN,
+		ifName:        n.IfName,
+		bytes:         n.BytesSent + n.BytesReceived,
+	}
+}
+
+// add sums the counters and gauges of another host into this one, which is used to build aggregate hosts. Timestamps and
+// flags can't be summed, so they are left unset.
+func (n *NtopHost) add(o *NtopHost) {
+	n.ActiveFlowsAsClient += o.ActiveFlowsAsClient
+	n.ActiveFlowsAsServer += o.ActiveFlowsAsServer
+	n.BytesReceived += o.BytesReceived
+	n.BytesSent += o.BytesSent
+	n.DNS.Received.add(&o.DNS.Received)
+	n.DNS.Sent.add(&o.DNS.Sent)
+	n.MisbehavingFlowsAsClient += o.MisbehavingFlowsAsClient
+	n.MisbehavingFlowsAsServer += o.MisbehavingFlowsAsServer
+	n.NumAlerts += o.NumAlerts
+	n.PacketsReceived += o.PacketsReceived
+	n.PacketsSent += o.PacketsSent
+	n.ScoreAsClient += o.ScoreAsClient
+	n.ScoreAsServer += o.ScoreAsServer
+	n.TCPPacketStatsReceived.add(&o.TCPPacketStatsReceived)
+	n.TCPPacketStatsSent.add(&o.TCPPacketStatsSent)
+	n.ThroughputBPS += o.ThroughputBPS
+	n.ThroughputPPS += o.ThroughputPPS
+	n.TotalAlerts += o.TotalAlerts
+	n.TotalFlowsAsClient += o.TotalFlowsAsClient
+	n.TotalFlowsAsServer += o.TotalFlowsAsServer
+	n.UnreachableFlowsAsClient += o.UnreachableFlowsAsClient
+	n.UnreachableFlowsAsServer += o.UnreachableFlowsAsServer
+	for _, otherValue := range o.Custom {
+		n.addCustomValue(otherValue)
+	}
+}
+
+// namedCounter points at a counter of a host or interface, along with the metric family that it is exported as named
+// without the namespace
+type namedCounter struct {
+	family string
+	value  *float64
+}
+
+// counters returns all of the built in counters of the host, always in the same order
+func (n *NtopHost) counters() []namedCounter {
+	counters := []namedCounter{
+		{"host_bytes_rcvd", &n.BytesReceived}, {"host_bytes_sent", &n.BytesSent},
+		{"host_packets_rcvd", &n.PacketsReceived}, {"host_packets_sent", &n.PacketsSent},
+		{"host_total_alerts", &n.TotalAlerts},
+		{"host_total_client_flows", &n.TotalFlowsAsClient}, {"host_total_server_flows", &n.TotalFlowsAsServer},
+		{"host_misbehaving_flows", &n.MisbehavingFlowsAsClient}, {"host_misbehaving_flows", &n.MisbehavingFlowsAsServer},
+		{"host_unreachable_flows", &n.UnreachableFlowsAsClient}, {"host_unreachable_flows", &n.UnreachableFlowsAsServer},
+	}
+	counters = append(counters, n.TCPPacketStatsReceived.counters("host_tcp_packet_stats")...)
+	counters = append(counters, n.TCPPacketStatsSent.counters("host_tcp_packet_stats")...)
+	counters = append(counters, n.DNS.Received.counters()...)
+	return append(counters, n.DNS.Sent.counters()...)
+}
+
+func (n *ntopTCPPacketStats) counters(family string) []namedCounter {
+	return []namedCounter{{family, &n.Lost}, {family, &n.OutOfOrder}, {family, &n.Retransmissions}}
+}
+
+func (n *NtopDNSSub) counters() []namedCounter {
+	const queriesByType = "host_dns_queries_by_type"
+	return []namedCounter{
+		{"host_total_dns_queries", &n.NumQueries},
+		{"host_total_dns_replies", &n.NumRepliesError}, {"host_total_dns_replies", &n.NumRepliesOK},
+		{queriesByType, &n.Queries.NumA}, {queriesByType, &n.Queries.NumAAAA}, {queriesByType, &n.Queries.NumAny},
+		{queriesByType, &n.Queries.NumCName}, {queriesByType, &n.Queries.NumMX}, {queriesByType, &n.Queries.NumNS},
+		{queriesByType, &n.Queries.NumOther}, {queriesByType, &n.Queries.NumPTR}, {queriesByType, &n.Queries.NumSOA},
+		{queriesByType, &n.Queries.NumTXT},
+	}
+}
+
+// counters returns all of the counters of the interface, always in the same order
+func (n *ntopInterfaceFull) counters() []namedCounter {
+	counters := []namedCounter{
+		{"interface_bytes_rcvd", &n.BytesReceived}, {"interface_bytes_sent", &n.BytesSent},
+		{"interface_drops", &n.Drops},
+		{"interface_packets_rcvd", &n.PacketsReceived}, {"interface_packets_sent", &n.PacketsSent},
+	}
+	return append(counters, n.TCPPacketStats.counters("interface_tcp_packet_stats")...)
+}
+
+// customValue returns the custom value of the host for the field and keys, or nil if the host doesn't have one
+func (n *NtopHost) customValue(field int, keys []string) *CustomHostValue {
+	for i := range n.Custom {
+		if n.Custom[i].Field == field && slices.Equal(n.Custom[i].Keys, keys) {
+			return &n.Custom[i]
+		}
+	}
+	return nil
+}
+
+// addCustomValue adds the value onto the matching custom value of this host, or appends it if there isn't one yet
+func (n *NtopHost) addCustomValue(o CustomHostValue) {
+	if value := n.customValue(o.Field, o.Keys); value != nil {
+		value.Value += o.Value
+		return
+	}
+	n.Custom = append(n.Custom, o)
+}
+
+func (n *ntopTCPPacketStats) add(o *ntopTCPPacketStats) {
+	n.Lost += o.Lost
+	n.OutOfOrder += o.OutOfOrder
+	n.Retransmissions += o.Retransmissions
+}
+
+func (n *NtopDNSSub) add(o *NtopDNSSub) {
+	n.NumQueries += o.NumQueries
+	n.NumRepliesError += o.NumRepliesError
+	n.NumRepliesOK += o.NumRepliesOK
+	n.Queries.NumA += o.Queries.NumA
+	n.Queries.NumAAAA += o.Queries.NumAAAA
+	n.Queries.NumAny += o.Queries.NumAny
+	n.Queries.NumCName += o.Queries.NumCName
+	n.Queries.NumMX += o.Queries.NumMX
+	n.Queries.NumNS += o.Queries.NumNS
+	n.Queries.NumOther += o.Queries.NumOther
+	n.Queries.NumPTR += o.Queries.NumPTR
+	n.Queries.NumSOA += o.Queries.NumSOA
+	n.Queries.NumTXT += o.Queries.NumTXT
+}
+
+func (n NtopHost) String() string {
 	output, _ := json.MarshalIndent(n, "", "\t")
 	return string(output)
 }
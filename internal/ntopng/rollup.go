@@ -0,0 +1,93 @@
+package ntopng
+
+import (
+	"fmt"
+	"net"
+	"strconv"
+
+	"github.com/aauren/ntopng-exporter/internal/config"
+)
+
+const (
+	ASNRollup     = "asn"
+	CountryRollup = "country"
+	SubnetRollup  = "subnet"
+	VLANRollup    = "vlan"
+)
+
+type rollupSubnet struct {
+	name   string
+	cidr   string
+	subnet *net.IPNet
+}
+
+// NtopRollup is an aggregate of all of the hosts on an interface that share a network segment or location. Name holds
+// the identifier of the group, such as the configured subnet name, the VLAN ID or the country code. Detail holds a
+// secondary description of the group where there is one, such as the CIDR of a subnet or the organization of an AS.
+type NtopRollup struct {
+	ActiveClientFlows float64
+	ActiveHosts       float64
+	ActiveServerFlows float64
+	BytesReceived     float64
+	BytesSent         float64
+	Detail            string
+	IfName            string
+	Name              string
+	PacketsReceived   float64
+	PacketsSent       float64
+}
+
+func newRollupSubnets(config *config.Config) []rollupSubnet {
+	var subnets []rollupSubnet
+	for _, subnet := range config.Metric.Rollups.Subnets {
+		_, parsedSubnet, _ := net.ParseCIDR(subnet.CIDR)
+		subnets = append(subnets, rollupSubnet{name: subnet.Name, cidr: subnet.CIDR, subnet: parsedSubnet})
+	}
+	return subnets
+}
+
+// rollupHosts adds the hosts of a single interface to the configured subnet, VLAN and GeoIP rollups. A host that is
+// contained in multiple overlapping subnets is counted in each of them, hosts without GeoIP information are skipped by
+// the country and AS rollups.
+func (c *Controller) rollupHosts(hosts []NtopHost, tempRollups map[string]map[string]NtopRollup) {
+	for i := range hosts {
+		myHost := &hosts[i]
+		if len(c.rollupSubnets) > 0 {
+			parsedIP := net.ParseIP(myHost.IP)
+			for _, subnet := range c.rollupSubnets {
+				if subnet.subnet.Contains(parsedIP) {
+					addHostToRollup(tempRollups, SubnetRollup, subnet.name, subnet.cidr, myHost)
+				}
+			}
+		}
+		if c.config.Metric.Rollups.VLANs {
+			addHostToRollup(tempRollups, VLANRollup, strconv.Itoa(myHost.VLAN), "", myHost)
+		}
+		if myHost.Geo.Country != "" {
+			addHostToRollup(tempRollups, CountryRollup, myHost.Geo.Country, "", myHost)
+		}
+		if myHost.Geo.ASN != "" {
+			addHostToRollup(tempRollups, ASNRollup, myHost.Geo.ASN, myHost.Geo.ASOrg, myHost)
+		}
+	}
+}
+
+func addHostToRollup(tempRollups map[string]map[string]NtopRollup, rollupType string, name string, detail string,
+	myHost *NtopHost) {
+	if _, ok := tempRollups[rollupType]; !ok {
+		tempRollups[rollupType] = make(map[string]NtopRollup)
+	}
+	key := fmt.Sprintf("%s/%s", name, myHost.IfName)
+	rollup, ok := tempRollups[rollupType][key]
+	if !ok {
+		rollup = NtopRollup{Name: name, Detail: detail, IfName: myHost.IfName}
+	}
+	rollup.ActiveClientFlows += myHost.ActiveFlowsAsClient
+	rollup.ActiveHosts++
+	rollup.ActiveServerFlows += myHost.ActiveFlowsAsServer
+	rollup.BytesReceived += myHost.BytesReceived
+	rollup.BytesSent += myHost.BytesSent
+	rollup.PacketsReceived += myHost.PacketsReceived
+	rollup.PacketsSent += myHost.PacketsSent
+	tempRollups[rollupType][key] = rollup
+}
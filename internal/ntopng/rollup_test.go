@@ -0,0 +1,121 @@
+package ntopng
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sync/atomic"
+	"testing"
+
+	"github.com/aauren/ntopng-exporter/internal/config"
+	"github.com/aauren/ntopng-exporter/internal/enrichment"
+)
+
+func TestRollupHosts(t *testing.T) {
+	rollupConfig := &config.Config{}
+	rollupConfig.Metric.Rollups.VLANs = true
+	unmarshalConfigKey(t, "subnets", []map[string]any{{"name": "lan", "cidr": "10.0.0.0/24"},
+		{"name": "servers", "cidr": "10.0.0.128/25"}, {"name": "v6", "cidr": "fd00::/8"}},
+		&rollupConfig.Metric.Rollups.Subnets)
+	c := &Controller{config: rollupConfig, rollupSubnets: newRollupSubnets(rollupConfig)}
+	host := func(ip string, ifName string, vlan int, bytesSent float64, geo enrichment.GeoInfo) NtopHost {
+		return NtopHost{IP: ip, IfName: ifName, VLAN: vlan, BytesSent: bytesSent, BytesReceived: 2 * bytesSent,
+			PacketsSent: 1, PacketsReceived: 2, ActiveFlowsAsClient: 3, ActiveFlowsAsServer: 4, Geo: geo}
+	}
+	us := enrichment.GeoInfo{Country: "US", ASN: "15169", ASOrg: "GOOGLE"}
+	hosts := []NtopHost{
+		host("10.0.0.1", "eth0", 0, 100, enrichment.GeoInfo{}),
+		// Within both of the overlapping subnets
+		host("10.0.0.200", "eth0", 10, 200, enrichment.GeoInfo{}),
+		host("fd00::1", "eth0", 10, 300, us),
+		// Outside of every subnet and with only a country
+		host("8.8.4.4", "eth0", 0, 400, enrichment.GeoInfo{Country: "US"}),
+		host("8.8.8.8", "eth1", 0, 500, us),
+		// An IP that doesn't parse isn't within any subnet
+		host("not an ip", "eth1", 0, 600, enrichment.GeoInfo{}),
+	}
+	rollup := func(name string, detail string, ifName string, hosts float64, bytesSent float64) NtopRollup {
+		return NtopRollup{Name: name, Detail: detail, IfName: ifName, ActiveHosts: hosts, BytesSent: bytesSent,
+			BytesReceived: 2 * bytesSent, PacketsSent: hosts, PacketsReceived: 2 * hosts, ActiveClientFlows: 3 * hosts,
+			ActiveServerFlows: 4 * hosts}
+	}
+	want := map[string]map[string]NtopRollup{
+		SubnetRollup: {
+			"lan/eth0":     rollup("lan", "10.0.0.0/24", "eth0", 2, 300),
+			"servers/eth0": rollup("servers", "10.0.0.128/25", "eth0", 1, 200),
+			"v6/eth0":      rollup("v6", "fd00::/8", "eth0", 1, 300),
+		},
+		VLANRollup: {
+			"0/eth0":  rollup("0", "", "eth0", 2, 500),
+			"10/eth0": rollup("10", "", "eth0", 2, 500),
+			"0/eth1":  rollup("0", "", "eth1", 2, 1100),
+		},
+		CountryRollup: {
+			"US/eth0": rollup("US", "", "eth0", 2, 700),
+			"US/eth1": rollup("US", "", "eth1", 1, 500),
+		},
+		ASNRollup: {
+			"15169/eth0": rollup("15169", "GOOGLE", "eth0", 1, 300),
+			"15169/eth1": rollup("15169", "GOOGLE", "eth1", 1, 500),
+		},
+	}
+	got := make(map[string]map[string]NtopRollup)
+	c.rollupHosts(hosts, got)
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("rollupHosts() = %+v, want %+v", got, want)
+	}
+
+	// Rollups that aren't configured are left out
+	c = &Controller{config: &config.Config{}}
+	got = make(map[string]map[string]NtopRollup)
+	c.rollupHosts(hosts[:3], got)
+	if _, ok := got[SubnetRollup]; ok {
+		t.Errorf("rollupHosts() without subnets = %+v, want no subnet rollups", got)
+	}
+	if _, ok := got[VLANRollup]; ok {
+		t.Errorf("rollupHosts() without VLAN rollups = %+v, want no VLAN rollups", got)
+	}
+}
+
+func TestRollupsLeaveOutRetainedHosts(t *testing.T) {
+	var scrapes atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		hosts := `{"IP": "10.0.0.1", "ifid": 0, "vlan": 0, "bytes.sent": 100}`
+		// The second host is missing from every scrape after the first one
+		if scrapes.Add(1) == 1 {
+			hosts += `, {"IP": "10.0.0.2", "ifid": 0, "vlan": 0, "bytes.sent": 200}`
+		}
+		_, _ = fmt.Fprintf(w, `{"rc_str": "OK", "rc": 0, "rsp": [%s]}`, hosts)
+	}))
+	defer server.Close()
+
+	rollupConfig := &config.Config{}
+	rollupConfig.Ntopng.EndPoint = server.URL
+	rollupConfig.Host.InterfacesToMonitor = []string{"eth0"}
+	rollupConfig.Metric.HostRetention = 1
+	rollupConfig.Metric.Rollups.VLANs = true
+	c := CreateController(rollupConfig, nil)
+	c.ifList = map[string]int{"eth0": 0}
+
+	for _, scrape := range []struct {
+		name           string
+		wantHosts      int
+		wantRollupHost float64
+		wantRollupSent float64
+	}{
+		{name: "both hosts are active", wantHosts: 2, wantRollupHost: 2, wantRollupSent: 300},
+		{name: "missing host is retained but not rolled up", wantHosts: 2, wantRollupHost: 1, wantRollupSent: 100},
+		{name: "missing host is no longer retained", wantHosts: 1, wantRollupHost: 1, wantRollupSent: 100},
+	} {
+		c.ScrapeHostEndpointForAllInterfaces()
+		if len(c.HostList) != scrape.wantHosts {
+			t.Errorf("%s: host list has %d hosts, want %d", scrape.name, len(c.HostList), scrape.wantHosts)
+		}
+		got := c.Rollups[VLANRollup]["0/eth0"]
+		if got.ActiveHosts != scrape.wantRollupHost || got.BytesSent != scrape.wantRollupSent {
+			t.Errorf("%s: VLAN rollup has %v hosts and %v bytes sent, want %v and %v", scrape.name, got.ActiveHosts,
+				got.BytesSent, scrape.wantRollupHost, scrape.wantRollupSent)
+		}
+	}
+}
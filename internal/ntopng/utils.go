@@ -1,10 +1,12 @@
 package ntopng
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
+	"net/url"
 	"strconv"
 )
 
@@ -35,8 +37,71 @@ func getRawJsonFromNtopResponse(body *[]byte) (json.RawMessage, error) {
 	return ntopResponse.Rsp, nil
 }
 
-func (c *Controller) checkForDuplicateInterfaces(myHost *ntopHost) error {
-	if host, ok := c.HostList[myHost.IP]; ok {
+// getNtopResponse performs a GET request against an ntopng REST v2 path and unmarshals the rsp portion of the response
+// into result
+func (c *Controller) getNtopResponse(path string, query url.Values, result any) error {
+	return c.getNtopResponseFromAPI(luaRestV2Get, path, query, result)
+}
+
+// getNtopProResponse is the same as getNtopResponse, but for paths that are only part of the ntopng Pro REST API
+func (c *Controller) getNtopProResponse(path string, query url.Values, result any) error {
+	return c.getNtopResponseFromAPI(luaProRestV2Get, path, query, result)
+}
+
+func (c *Controller) getNtopResponseFromAPI(api string, path string, query url.Values, result any) error {
+	endpoint := fmt.Sprintf("%s%s%s", c.config.Ntopng.EndPoint, api, path)
+	if len(query) > 0 {
+		endpoint = fmt.Sprintf("%s?%s", endpoint, query.Encode())
+	}
+	req, err := http.NewRequestWithContext(context.Background(), "GET", endpoint, nil)
+	if err != nil {
+		return err
+	}
+	c.setCommonOptions(req, false)
+
+	body, status, _ := getHttpResponseBody(getHttpClient(c.config.Ntopng.AllowUnsafeTLS), req)
+	if status != http.StatusOK {
+		if body != nil {
+			return fmt.Errorf("request to '%s' was not successful. Status: '%d', Response: '%v'",
+				endpoint, status, *body)
+		} else {
+			return fmt.Errorf("request to '%s' was not successful. Status: '%d'",
+				endpoint, status)
+		}
+	}
+
+	rawResponse, err := getRawJsonFromNtopResponse(body)
+	if err != nil {
+		return err
+	}
+	if err = json.Unmarshal(rawResponse, result); err != nil {
+		return fmt.Errorf("was not able to parse response from '%s': %v", endpoint, err)
+	}
+	return nil
+}
+
+// scrapeListForAllInterfaces requests a list endpoint for every monitored interface, passing the interface's ifid, and
+// collects the items into a single map. key is called for every item with the name of the interface that it came from,
+// and returns the key to store the item under, it may also modify the item (e.g. to record the interface name).
+func scrapeListForAllInterfaces[T any](c *Controller, path string, key func(item *T, ifName string) string) map[string]T {
+	tempList := make(map[string]T)
+	for _, configuredIf := range c.config.Host.InterfacesToMonitor {
+		var items []T
+		query := url.Values{"ifid": []string{strconv.Itoa(c.ifList[configuredIf])}}
+		if err := c.getNtopResponse(path, query, &items); err != nil {
+			fmt.Printf("failed to scrape '%s' for interface '%s' with error: %v\n", path, configuredIf, err)
+			continue
+		}
+		for i := range items {
+			tempList[key(&items[i], configuredIf)] = items[i]
+		}
+	}
+	return tempList
+}
+
+func (c *Controller) checkForDuplicateInterfaces(myHost *NtopHost) error {
+	// Retained hosts are skipped, as they have most likely moved to the other interface
+	if host, ok := c.HostList[myHost.IP]; ok && host.missedScrapes == 0 {
 		if host.IfID != myHost.IfID {
 			ifName1, err := c.ResolveIfID(host.IfID)
 			if err != nil {
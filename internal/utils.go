@@ -1,5 +1,7 @@
 package internal
 
+import "strings"
+
 func IsItemInArray(haystack []string, needle string) bool {
 	for _, hay := range haystack {
 		if needle == hay {
@@ -8,3 +10,15 @@ func IsItemInArray(haystack []string, needle string) bool {
 	}
 	return false
 }
+
+// NormalizeMAC lower cases a MAC address or prefix and strips out any separators so that the different notations
+// (aa:bb:cc, AA-BB-CC, aabb.cc) compare equally
+func NormalizeMAC(mac string) string {
+	return strings.Map(func(r rune) rune {
+		switch r {
+		case ':', '-', '.':
+			return -1
+		}
+		return r
+	}, strings.ToLower(mac))
+}
@@ -3,13 +3,13 @@ package main
 import (
 	"context"
 	"fmt"
+	"log"
 	"net/http"
 	"os"
 	"os/signal"
 	"syscall"
 	"time"
 
-	"github.com/aauren/ntopng-exporter/internal"
 	"github.com/aauren/ntopng-exporter/internal/config"
 	ntopPrometheus "github.com/aauren/ntopng-exporter/internal/metrics/prometheus"
 	"github.com/aauren/ntopng-exporter/internal/ntopng"
@@ -36,6 +36,11 @@ func main() {
 		fmt.Printf("failed to cache interface ids: %v\n", err)
 		os.Exit(2)
 	}
+	err = ntopControl.LoadEnrichment()
+	if err != nil {
+		fmt.Printf("failed to load enrichment sources: %v\n", err)
+		os.Exit(3)
+	}
 	ntopControl.ScrapeAllConfiguredTargets()
 	go ntopControl.RunController()
 
@@ -59,18 +64,64 @@ func main() {
 }
 
 func serveMetrics(ntopController *ntopng.Controller, myConfig *config.Config) *http.Server {
-	if internal.IsItemInArray(myConfig.Ntopng.ScrapeTargets, config.HostScrape) ||
-		internal.IsItemInArray(myConfig.Ntopng.ScrapeTargets, config.AllScrape) {
+	if myConfig.IsScrapeTargetEnabled(config.HostScrape) {
 		ntopCollector := ntopPrometheus.NewNtopNGHostCollector(ntopController, myConfig)
 		prometheus.MustRegister(ntopCollector)
+		if len(myConfig.Metric.Rollups.Subnets) > 0 {
+			prometheus.MustRegister(ntopPrometheus.NewNtopNGSubnetCollector(ntopController, myConfig))
+		}
+		if myConfig.Metric.Rollups.VLANs {
+			prometheus.MustRegister(ntopPrometheus.NewNtopNGVLANCollector(ntopController, myConfig))
+		}
+		if myConfig.Enrichment.GeoIP.CountryDatabase != "" || myConfig.Enrichment.GeoIP.CityDatabase != "" {
+			prometheus.MustRegister(ntopPrometheus.NewNtopNGCountryCollector(ntopController, myConfig))
+		}
+		if myConfig.Enrichment.GeoIP.ASNDatabase != "" {
+			prometheus.MustRegister(ntopPrometheus.NewNtopNGASNCollector(ntopController, myConfig))
+		}
 	}
-	if internal.IsItemInArray(myConfig.Ntopng.ScrapeTargets, config.InterfaceScrape) ||
-		internal.IsItemInArray(myConfig.Ntopng.ScrapeTargets, config.AllScrape) {
+	if myConfig.IsScrapeTargetEnabled(config.InterfaceScrape) {
 		ntopCollector := ntopPrometheus.NewNtopNGInterfaceCollector(ntopController, myConfig)
 		prometheus.MustRegister(ntopCollector)
 	}
+	if myConfig.IsScrapeTargetEnabled(config.ASScrape) {
+		prometheus.MustRegister(ntopPrometheus.NewNtopNGASStatsCollector(ntopController, myConfig))
+	}
+	if myConfig.IsScrapeTargetEnabled(config.CountryScrape) {
+		prometheus.MustRegister(ntopPrometheus.NewNtopNGCountryStatsCollector(ntopController, myConfig))
+	}
+	if myConfig.IsScrapeTargetEnabled(config.OSScrape) {
+		prometheus.MustRegister(ntopPrometheus.NewNtopNGOSStatsCollector(ntopController, myConfig))
+	}
+	if myConfig.IsScrapeTargetEnabled(config.MACScrape) {
+		prometheus.MustRegister(ntopPrometheus.NewNtopNGMACCollector(ntopController, myConfig))
+	}
+	if myConfig.IsScrapeTargetEnabled(config.PoolScrape) {
+		prometheus.MustRegister(ntopPrometheus.NewNtopNGPoolCollector(ntopController, myConfig))
+	}
+	if myConfig.IsScrapeTargetEnabled(config.NetworkScrape) {
+		prometheus.MustRegister(ntopPrometheus.NewNtopNGNetworkCollector(ntopController, myConfig))
+	}
+	if myConfig.IsScrapeTargetEnabled(config.SystemScrape) {
+		prometheus.MustRegister(ntopPrometheus.NewNtopNGSystemCollector(ntopController, myConfig))
+	}
+	if myConfig.IsScrapeTargetEnabled(config.FlowDeviceScrape) {
+		prometheus.MustRegister(ntopPrometheus.NewNtopNGFlowDeviceCollector(ntopController, myConfig))
+	}
+	if myConfig.IsScrapeTargetEnabled(config.SNMPScrape) {
+		prometheus.MustRegister(ntopPrometheus.NewNtopNGSNMPCollector(ntopController, myConfig))
+	}
+	if myConfig.IsScrapeTargetEnabled(config.CustomScrape) {
+		prometheus.MustRegister(ntopPrometheus.NewNtopNGCustomCollector(ntopController, myConfig))
+	}
+	prometheus.MustRegister(ntopPrometheus.NewNtopNGExporterCollector(ntopController, myConfig))
+	// Invalid metrics are logged and left out, rather than failing the whole scrape
+	handler := promhttp.HandlerFor(prometheus.DefaultGatherer, promhttp.HandlerOpts{
+		ErrorLog:      log.New(os.Stdout, "", log.LstdFlags),
+		ErrorHandling: promhttp.ContinueOnError,
+	})
 	mux := http.NewServeMux()
-	mux.Handle("/metrics", promhttp.Handler())
+	mux.Handle("/metrics", promhttp.InstrumentMetricHandler(prometheus.DefaultRegisterer, handler))
 
 	srv := &http.Server{
 		Addr:              fmt.Sprintf("%s:%d", myConfig.Metric.Serve.IP, myConfig.Metric.Serve.Port),